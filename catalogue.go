@@ -0,0 +1,418 @@
+package newsdoc
+
+import (
+	"fmt"
+	"slices"
+)
+
+// TypeCatalogue describes the known blocks of a set of documents, and is used
+// to statically check value extractor expressions, see TypeCatalogue.Check().
+type TypeCatalogue struct {
+	Meta    BlockCatalogue `json:"meta,omitempty"`
+	Links   BlockCatalogue `json:"links,omitempty"`
+	Content BlockCatalogue `json:"content,omitempty"`
+}
+
+// BlockCatalogue describes the blocks that can appear in a block list, keyed
+// by block type. Use an empty string as the key for blocks without a type.
+type BlockCatalogue map[string]BlockSpec
+
+// BlockSpec describes a block type.
+type BlockSpec struct {
+	// Rels are the rels that the block can have.
+	Rels []string `json:"rels,omitempty"`
+	// DataKeys are the data keys that the block can have.
+	DataKeys []string `json:"datakeys,omitempty"`
+	// Meta describes the meta blocks of the block.
+	Meta BlockCatalogue `json:"meta,omitempty"`
+	// Links describes the links of the block.
+	Links BlockCatalogue `json:"links,omitempty"`
+	// Content describes the content blocks of the block.
+	Content BlockCatalogue `json:"content,omitempty"`
+}
+
+func (cat TypeCatalogue) blocks(kind BlockKind) BlockCatalogue {
+	switch kind {
+	case BlockKindMeta:
+		return cat.Meta
+	case BlockKindLinks:
+		return cat.Links
+	case BlockKindContent:
+		return cat.Content
+	}
+
+	return nil
+}
+
+func (spec BlockSpec) blocks(kind BlockKind) BlockCatalogue {
+	switch kind {
+	case BlockKindMeta:
+		return spec.Meta
+	case BlockKindLinks:
+		return spec.Links
+	case BlockKindContent:
+		return spec.Content
+	}
+
+	return nil
+}
+
+// ExtractorPart identifies a part of a ValueExtractor.
+type ExtractorPart string
+
+const (
+	ExtractorPartSelector      ExtractorPart = "selector"
+	ExtractorPartChildSelector ExtractorPart = "child-selector"
+	ExtractorPartValue         ExtractorPart = "value"
+)
+
+// ExtractorPosition is the position of a selector or value in a
+// ValueExtractor.
+type ExtractorPosition struct {
+	Part  ExtractorPart
+	Index int
+}
+
+// String returns the position in the form "selector 1".
+func (p ExtractorPosition) String() string {
+	return fmt.Sprintf("%s %d", p.Part, p.Index)
+}
+
+// ExtractorWarningCode categorises warnings from TypeCatalogue.Check().
+type ExtractorWarningCode string
+
+const (
+	WarningUnknownType      ExtractorWarningCode = "unknown-type"
+	WarningUnknownRel       ExtractorWarningCode = "unknown-rel"
+	WarningUnknownDataKey   ExtractorWarningCode = "unknown-data-key"
+	WarningUnknownAttribute ExtractorWarningCode = "unknown-attribute"
+	WarningImpossibleChain  ExtractorWarningCode = "impossible-chain"
+)
+
+// ExtractorWarning is a problem found when checking a ValueExtractor.
+type ExtractorWarning struct {
+	Position ExtractorPosition
+	Code     ExtractorWarningCode
+	Message  string
+}
+
+// String returns the warning in the form "selector 1: message".
+func (w ExtractorWarning) String() string {
+	return w.Position.String() + ": " + w.Message
+}
+
+// Check checks the extractor against the catalogue, and reports unknown types,
+// rels, data keys and attributes, and selectors that can't match any known
+// block. An empty result means that the expression is consistent with the
+// catalogue, not that it will return values.
+func (cat TypeCatalogue) Check(ve *ValueExtractor) []ExtractorWarning {
+	c := catalogueChecker{}
+
+	if len(ve.Selectors) == 0 {
+		for i, v := range ve.Values {
+			switch documentAttributeKey(v.Name) {
+			case docAttrType, docAttrLanguage, docAttrTitle,
+				docAttrUUID, docAttrURI, docAttrURL:
+				continue
+			}
+
+			c.warn(ExtractorPartValue, i, WarningUnknownAttribute,
+				"unknown document attribute %q", v.Name)
+		}
+
+		return c.warnings
+	}
+
+	candidates := cat.blocks(ve.Selectors[0].Kind)
+
+	specs, ok := c.checkChain(
+		ExtractorPartSelector, ve.Selectors, []BlockCatalogue{candidates})
+	if !ok {
+		return c.warnings
+	}
+
+	if len(ve.ChildSelectors) > 0 {
+		var lists []BlockCatalogue
+
+		for _, s := range specs {
+			lists = append(lists, s.blocks(ve.ChildSelectors[0].Kind))
+		}
+
+		_, ok := c.checkChain(
+			ExtractorPartChildSelector, ve.ChildSelectors, lists)
+		if !ok {
+			return c.warnings
+		}
+	}
+
+	c.checkValues(ve, specs)
+
+	return c.warnings
+}
+
+type catalogueChecker struct {
+	warnings []ExtractorWarning
+}
+
+func (c *catalogueChecker) warn(
+	part ExtractorPart, index int, code ExtractorWarningCode,
+	format string, a ...any,
+) {
+	c.warnings = append(c.warnings, ExtractorWarning{
+		Position: ExtractorPosition{Part: part, Index: index},
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// checkChain checks a selector chain starting with the given block lists. It
+// returns the specs of the blocks that the last selector can match, and false
+// if the chain can't match any block.
+func (c *catalogueChecker) checkChain(
+	part ExtractorPart, selectors []BlockSelector, lists []BlockCatalogue,
+) ([]BlockSpec, bool) {
+	var specs []BlockSpec
+
+	for i, sel := range selectors {
+		if i > 0 {
+			lists = nil
+
+			for _, s := range specs {
+				lists = append(lists, s.blocks(sel.Kind))
+			}
+		}
+
+		var (
+			candidates = make(map[string]BlockSpec)
+			matched    []BlockSpec
+		)
+
+		for _, list := range lists {
+			for t, spec := range list {
+				candidates[t] = mergeSpecs(candidates[t], spec)
+			}
+		}
+
+		if len(candidates) == 0 {
+			c.warn(part, i, WarningImpossibleChain,
+				"there are no known %s blocks at %s", sel.Kind, sel)
+
+			return nil, false
+		}
+
+		if sel.Filter != nil {
+			c.checkLeaves(part, i, sel.Kind, sel.Filter, candidates)
+		}
+
+		for _, t := range sortedKeys(candidates) {
+			if sel.Filter.couldMatch(t, candidates[t]) == matchNo {
+				continue
+			}
+
+			matched = append(matched, candidates[t])
+		}
+
+		if len(matched) == 0 {
+			c.warn(part, i, WarningImpossibleChain,
+				"%s can't match any known block", sel)
+
+			return nil, false
+		}
+
+		specs = matched
+	}
+
+	return specs, true
+}
+
+// checkLeaves reports unknown types, rels and data keys in the filter leaves.
+func (c *catalogueChecker) checkLeaves(
+	part ExtractorPart, index int, kind BlockKind,
+	fn *FilterNode, candidates map[string]BlockSpec,
+) {
+	if fn.Op != "" {
+		for i := range fn.Children {
+			c.checkLeaves(part, index, kind, &fn.Children[i], candidates)
+		}
+
+		return
+	}
+
+	switch {
+	case fn.Data != nil:
+		for _, spec := range candidates {
+			if slices.Contains(spec.DataKeys, fn.Data.Key) {
+				return
+			}
+		}
+
+		c.warn(part, index, WarningUnknownDataKey,
+			"unknown data key %q in %s blocks", fn.Data.Key, kind)
+	case blockAttributeKey(fn.Attr) == blockAttrType:
+		if _, ok := candidates[fn.Value]; ok {
+			return
+		}
+
+		c.warn(part, index, WarningUnknownType,
+			"unknown %s type %q", kind, fn.Value)
+	case blockAttributeKey(fn.Attr) == blockAttrRel && fn.Value != "":
+		for _, spec := range candidates {
+			if slices.Contains(spec.Rels, fn.Value) {
+				return
+			}
+		}
+
+		c.warn(part, index, WarningUnknownRel,
+			"unknown %s rel %q", kind, fn.Value)
+	}
+}
+
+func (c *catalogueChecker) checkValues(ve *ValueExtractor, specs []BlockSpec) {
+	for i, v := range ve.Values {
+		isData := ve.ValueKind == ValueKindData ||
+			(ve.ValueKind == ValueKindCombined &&
+				v.Source == ValueSourceData)
+
+		switch {
+		case ve.ValueKind == ValueKindBlock:
+			continue
+		case isData:
+			known := slices.ContainsFunc(specs, func(s BlockSpec) bool {
+				return slices.Contains(s.DataKeys, v.Name)
+			})
+			if known {
+				continue
+			}
+
+			c.warn(ExtractorPartValue, i, WarningUnknownDataKey,
+				"unknown data key %q in the selected blocks", v.Name)
+		default:
+			if isBlockAttribute(v.Name) {
+				continue
+			}
+
+			c.warn(ExtractorPartValue, i, WarningUnknownAttribute,
+				"unknown block attribute %q", v.Name)
+		}
+	}
+}
+
+// mergeSpecs merges two specs for the same block type, used when a selector
+// can reach the same type in different parents.
+func mergeSpecs(a, b BlockSpec) BlockSpec {
+	mergeLists := func(a, b BlockCatalogue) BlockCatalogue {
+		if len(a) == 0 {
+			return b
+		}
+
+		m := make(BlockCatalogue, len(a)+len(b))
+
+		for t, s := range a {
+			m[t] = s
+		}
+
+		for t, s := range b {
+			m[t] = mergeSpecs(m[t], s)
+		}
+
+		return m
+	}
+
+	return BlockSpec{
+		Rels:     compactUnion(a.Rels, b.Rels),
+		DataKeys: compactUnion(a.DataKeys, b.DataKeys),
+		Meta:     mergeLists(a.Meta, b.Meta),
+		Links:    mergeLists(a.Links, b.Links),
+		Content:  mergeLists(a.Content, b.Content),
+	}
+}
+
+func compactUnion(a, b []string) []string {
+	u := slices.Concat(a, b)
+
+	slices.Sort(u)
+
+	return slices.Compact(u)
+}
+
+func sortedKeys(m map[string]BlockSpec) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+type matchResult int
+
+const (
+	matchNo matchResult = iota
+	matchMaybe
+	matchYes
+)
+
+// couldMatch evaluates the filter against a block spec using three-valued
+// logic, as only the type, rels and data keys of the block are known.
+func (fn *FilterNode) couldMatch(blockType string, spec BlockSpec) matchResult {
+	if fn == nil {
+		return matchYes
+	}
+
+	switch fn.Op {
+	case FilterOpAnd:
+		res := matchYes
+
+		for i := range fn.Children {
+			res = min(res, fn.Children[i].couldMatch(blockType, spec))
+		}
+
+		return res
+	case FilterOpOr:
+		res := matchNo
+
+		for i := range fn.Children {
+			res = max(res, fn.Children[i].couldMatch(blockType, spec))
+		}
+
+		return res
+	}
+
+	if fn.Data != nil {
+		known := slices.Contains(spec.DataKeys, fn.Data.Key)
+
+		switch {
+		case known:
+			return matchMaybe
+		case fn.Data.Mode == DataFilterExact && fn.Data.Value == "":
+			return matchYes
+		default:
+			return matchNo
+		}
+	}
+
+	switch blockAttributeKey(fn.Attr) {
+	case blockAttrType:
+		if fn.Value == blockType {
+			return matchYes
+		}
+
+		return matchNo
+	case blockAttrRel:
+		switch {
+		case slices.Contains(spec.Rels, fn.Value):
+			return matchMaybe
+		case fn.Value == "" && len(spec.Rels) == 0:
+			return matchYes
+		case fn.Value == "":
+			return matchMaybe
+		default:
+			return matchNo
+		}
+	default:
+		return matchMaybe
+	}
+}
@@ -0,0 +1,123 @@
+package newsdoc_test
+
+import (
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func planningCatalogue() newsdoc.TypeCatalogue {
+	return newsdoc.TypeCatalogue{
+		Meta: newsdoc.BlockCatalogue{
+			"core/planning-item": {
+				DataKeys: []string{
+					"start_date", "end_date", "date_tz",
+					"public", "tentative",
+				},
+			},
+			"core/description": {
+				DataKeys: []string{"text"},
+			},
+			"core/newsvalue": {},
+			"core/assignment": {
+				DataKeys: []string{
+					"start", "end", "start_date", "end_date",
+					"date_tz", "full_day", "public",
+				},
+				Meta: newsdoc.BlockCatalogue{
+					"core/assignment-type": {},
+				},
+				Links: newsdoc.BlockCatalogue{
+					"core/article": {Rels: []string{"deliverable"}},
+					"tt/wire":      {Rels: []string{"source-document"}},
+				},
+			},
+		},
+		Links: newsdoc.BlockCatalogue{
+			"core/event":   {Rels: []string{"event"}},
+			"core/section": {Rels: []string{"section"}},
+		},
+	}
+}
+
+type catalogueWarning struct {
+	Position newsdoc.ExtractorPosition
+	Code     newsdoc.ExtractorWarningCode
+}
+
+func TestTypeCatalogueCheck(t *testing.T) {
+	cat := planningCatalogue()
+
+	cases := map[string][]catalogueWarning{
+		".meta(type='core/planning-item').data{start_date, date_tz?}":   nil,
+		".meta(type='core/assignment').links(rel='deliverable')@{uuid}": nil,
+		"a=.meta(type='core/assignment')#.links(rel='deliverable')":     nil,
+		".meta(type='core/assignment' (data.public='true' or data.x?))@{title}": {
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartSelector}, newsdoc.WarningUnknownDataKey},
+		},
+		".meta(type='core/assigment').data{start_date}": {
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartSelector}, newsdoc.WarningUnknownType},
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartSelector}, newsdoc.WarningImpossibleChain},
+		},
+		".meta(type='core/planning-item').data{strat_date}": {
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartValue}, newsdoc.WarningUnknownDataKey},
+		},
+		".meta(type='core/assignment').links(rel='deliverables')@{uuid}": {
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartSelector, Index: 1}, newsdoc.WarningUnknownRel},
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartSelector, Index: 1}, newsdoc.WarningImpossibleChain},
+		},
+		".meta(type='core/newsvalue').links@{uuid}": {
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartSelector, Index: 1}, newsdoc.WarningImpossibleChain},
+		},
+		// Both types and rels exist, but not in the same block.
+		".links(type='core/event' rel='section')@{uuid}": {
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartSelector}, newsdoc.WarningImpossibleChain},
+		},
+		"a=.meta(type='core/assignment')#.content": {
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartChildSelector}, newsdoc.WarningImpossibleChain},
+		},
+		".meta(type='core/assignment')@{titel}.data{start}": {
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartValue}, newsdoc.WarningUnknownAttribute},
+		},
+		"@{title langauge?}": {
+			{newsdoc.ExtractorPosition{Part: newsdoc.ExtractorPartValue, Index: 1}, newsdoc.WarningUnknownAttribute},
+		},
+	}
+
+	for exp, want := range cases {
+		ve, err := newsdoc.ValueExtractorFromString(exp)
+		test.Mustf(t, err, "parse expression %q", exp)
+
+		var got []catalogueWarning
+
+		for _, w := range cat.Check(ve) {
+			got = append(got, catalogueWarning{
+				Position: w.Position,
+				Code:     w.Code,
+			})
+
+			t.Logf("%s: %s", exp, w)
+		}
+
+		test.EqualDiffWithOptionsf(t, want, got, nil,
+			"warnings for %q", exp)
+	}
+}
+
+func TestExtractorWarningString(t *testing.T) {
+	ve, err := newsdoc.ValueExtractorFromString(
+		".meta(type='core/assigment').data{start_date}")
+	test.Mustf(t, err, "parse expression")
+
+	warnings := planningCatalogue().Check(ve)
+	if len(warnings) == 0 {
+		t.Fatal("expected warnings")
+	}
+
+	want := `selector 0: unknown meta type "core/assigment"`
+
+	if warnings[0].String() != want {
+		t.Errorf("expected %q, got %q", want, warnings[0].String())
+	}
+}
@@ -0,0 +1,762 @@
+package newsdoc
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// OperationType is the type of an operation in a Changeset.
+type OperationType string
+
+const (
+	// OpInsertBlock inserts a block at a path.
+	OpInsertBlock OperationType = "insert_block"
+	// OpDeleteBlock deletes the block at a path.
+	OpDeleteBlock OperationType = "delete_block"
+	// OpSetAttribute sets an attribute of the block at a path, or of the
+	// document if the path is empty.
+	OpSetAttribute OperationType = "set_attribute"
+	// OpSetData sets a data value of the block at a path.
+	OpSetData OperationType = "set_data"
+	// OpDeleteData deletes a data value of the block at a path.
+	OpDeleteData OperationType = "delete_data"
+)
+
+// Operation is a single change to a document.
+type Operation struct {
+	Type OperationType `json:"op"`
+	Path BlockPath     `json:"path,omitempty"`
+	// Block is the inserted block, or the deleted block for delete
+	// operations.
+	Block *Block `json:"block,omitempty"`
+	// Name is the attribute name or data key.
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+	// Previous is the value before the operation was applied, nil means
+	// that the data key was unset.
+	Previous *string `json:"previous,omitempty"`
+}
+
+// Changeset is a log of operations made to a document. Changesets are recorded
+// using a Recorder, and can be replayed onto a document using Apply().
+type Changeset struct {
+	Operations []Operation `json:"operations"`
+}
+
+// Apply applies the operations in the changeset to the document in order.
+// Application stops at the first operation that fails.
+func (cs Changeset) Apply(doc *Document) error {
+	for i, op := range cs.Operations {
+		err := op.Apply(doc)
+		if err != nil {
+			return fmt.Errorf("operation %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Invert returns a changeset that undoes the changeset. Delete operations must
+// carry the deleted block, and attribute operations the previous value, to be
+// invertible.
+func (cs Changeset) Invert() (Changeset, error) {
+	inv := Changeset{
+		Operations: make([]Operation, len(cs.Operations)),
+	}
+
+	for i, op := range cs.Operations {
+		o, err := op.Invert()
+		if err != nil {
+			return Changeset{}, fmt.Errorf("operation %d: %w", i+1, err)
+		}
+
+		inv.Operations[len(cs.Operations)-1-i] = o
+	}
+
+	return inv, nil
+}
+
+// Invert returns the operation that undoes the operation.
+func (op Operation) Invert() (Operation, error) {
+	switch op.Type {
+	case OpInsertBlock:
+		return Operation{
+			Type:  OpDeleteBlock,
+			Path:  op.Path,
+			Block: op.Block,
+		}, nil
+	case OpDeleteBlock:
+		if op.Block == nil {
+			return Operation{}, errors.New(
+				"delete operation without the deleted block")
+		}
+
+		return Operation{
+			Type:  OpInsertBlock,
+			Path:  op.Path,
+			Block: op.Block,
+		}, nil
+	case OpSetAttribute:
+		if op.Previous == nil {
+			return Operation{}, errors.New(
+				"set attribute operation without the previous value")
+		}
+
+		return Operation{
+			Type:     OpSetAttribute,
+			Path:     op.Path,
+			Name:     op.Name,
+			Value:    *op.Previous,
+			Previous: &op.Value,
+		}, nil
+	case OpSetData, OpDeleteData:
+		var current *string
+
+		if op.Type == OpSetData {
+			current = &op.Value
+		}
+
+		if op.Previous == nil {
+			return Operation{
+				Type:     OpDeleteData,
+				Path:     op.Path,
+				Name:     op.Name,
+				Previous: current,
+			}, nil
+		}
+
+		return Operation{
+			Type:     OpSetData,
+			Path:     op.Path,
+			Name:     op.Name,
+			Value:    *op.Previous,
+			Previous: current,
+		}, nil
+	}
+
+	return Operation{}, fmt.Errorf("unknown operation type %q", op.Type)
+}
+
+// Apply applies the operation to the document.
+func (op Operation) Apply(doc *Document) error {
+	_, err := op.apply(doc)
+
+	return err
+}
+
+// apply applies the operation to the document and returns the operation with
+// the state needed to invert it.
+func (op Operation) apply(doc *Document) (Operation, error) {
+	switch op.Type {
+	case OpInsertBlock:
+		if op.Block == nil {
+			return op, errors.New("insert operation without a block")
+		}
+
+		// Keep the recorded block separate from both the caller's
+		// block and the inserted block.
+		recorded := op.Block.Clone()
+		op.Block = &recorded
+
+		err := alterBlockList(doc, op.Path, func(list []Block, idx int) ([]Block, error) {
+			if idx > len(list) {
+				return nil, fmt.Errorf("cannot insert at %s", op.Path)
+			}
+
+			return slices.Insert(list, idx, op.Block.Clone()), nil
+		})
+
+		return op, err
+	case OpDeleteBlock:
+		err := alterBlockList(doc, op.Path, func(list []Block, idx int) ([]Block, error) {
+			if idx >= len(list) {
+				return nil, fmt.Errorf("no block at %s", op.Path)
+			}
+
+			deleted := list[idx]
+			op.Block = &deleted
+
+			return slices.Delete(list, idx, idx+1), nil
+		})
+
+		return op, err
+	case OpSetAttribute:
+		if len(op.Path) == 0 {
+			previous := getDocumentAttribute(*doc, op.Name)
+			op.Previous = &previous
+
+			if !setDocumentAttribute(doc, op.Name, op.Value) {
+				return op, fmt.Errorf(
+					"unknown document attribute: %s", op.Name)
+			}
+
+			return op, nil
+		}
+
+		err := alterBlock(doc, op.Path, func(b *Block) error {
+			previous := getBlockAttribute(*b, op.Name)
+			op.Previous = &previous
+
+			if !setBlockAttribute(b, op.Name, op.Value) {
+				return fmt.Errorf("unknown attribute key: %s", op.Name)
+			}
+
+			return nil
+		})
+
+		return op, err
+	case OpSetData, OpDeleteData:
+		err := alterBlock(doc, op.Path, func(b *Block) error {
+			op.Previous = nil
+
+			if v, ok := b.Data[op.Name]; ok {
+				op.Previous = &v
+			}
+
+			if op.Type == OpDeleteData {
+				b.Data.Delete(op.Name)
+
+				return nil
+			}
+
+			if b.Data == nil {
+				b.Data = make(DataMap)
+			}
+
+			b.Data[op.Name] = op.Value
+
+			return nil
+		})
+
+		return op, err
+	}
+
+	return op, fmt.Errorf("unknown operation type %q", op.Type)
+}
+
+// alterBlock calls fn with the block at the path.
+func alterBlock(doc *Document, path BlockPath, fn func(b *Block) error) error {
+	return alterBlockList(doc, path, func(list []Block, idx int) ([]Block, error) {
+		if idx >= len(list) {
+			return nil, fmt.Errorf("no block at %s", path)
+		}
+
+		return list, fn(&list[idx])
+	})
+}
+
+// alterBlockList calls fn with the block list that the last step of the path
+// indexes into, and replaces the list with the one returned by fn.
+func alterBlockList(
+	doc *Document, path BlockPath,
+	fn func(list []Block, idx int) ([]Block, error),
+) error {
+	if len(path) == 0 {
+		return errors.New("empty block path")
+	}
+
+	kind := path[0].Kind
+
+	list, err := alterBlockListAt(
+		documentBlocks(*doc, kind), path, 0, fn)
+	if err != nil {
+		return err
+	}
+
+	setDocumentBlocks(doc, kind, list)
+
+	return nil
+}
+
+func alterBlockListAt(
+	list []Block, path BlockPath, depth int,
+	fn func(list []Block, idx int) ([]Block, error),
+) ([]Block, error) {
+	step := path[depth]
+
+	if step.Index < 0 {
+		return nil, fmt.Errorf("invalid index in %s", path)
+	}
+
+	if depth == len(path)-1 {
+		return fn(list, step.Index)
+	}
+
+	if step.Index >= len(list) {
+		return nil, fmt.Errorf("no block at %s", path[:depth+1])
+	}
+
+	kind := path[depth+1].Kind
+
+	children, err := alterBlockListAt(
+		childBlocks(list[step.Index], kind), path, depth+1, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	setChildBlocks(&list[step.Index], kind, children)
+
+	return list, nil
+}
+
+// Recorder makes changes to a document and records them in a changeset.
+type Recorder struct {
+	doc *Document
+	cs  Changeset
+}
+
+// NewRecorder creates a recorder that makes changes to the document.
+func NewRecorder(doc *Document) *Recorder {
+	return &Recorder{doc: doc}
+}
+
+// Changeset returns the operations recorded so far.
+func (r *Recorder) Changeset() Changeset {
+	return Changeset{
+		Operations: slices.Clone(r.cs.Operations),
+	}
+}
+
+// Apply applies and records an operation.
+func (r *Recorder) Apply(op Operation) error {
+	applied, err := op.apply(r.doc)
+	if err != nil {
+		return err
+	}
+
+	r.cs.Operations = append(r.cs.Operations, applied)
+
+	return nil
+}
+
+// InsertBlock inserts a block at the path.
+func (r *Recorder) InsertBlock(path BlockPath, b Block) error {
+	return r.Apply(Operation{
+		Type:  OpInsertBlock,
+		Path:  path,
+		Block: &b,
+	})
+}
+
+// DeleteBlock deletes the block at the path.
+func (r *Recorder) DeleteBlock(path BlockPath) error {
+	return r.Apply(Operation{
+		Type: OpDeleteBlock,
+		Path: path,
+	})
+}
+
+// SetAttribute sets an attribute of the block at the path, or a document
+// attribute if the path is empty.
+func (r *Recorder) SetAttribute(path BlockPath, name string, value string) error {
+	return r.Apply(Operation{
+		Type:  OpSetAttribute,
+		Path:  path,
+		Name:  name,
+		Value: value,
+	})
+}
+
+// SetData sets a data value of the block at the path.
+func (r *Recorder) SetData(path BlockPath, key string, value string) error {
+	return r.Apply(Operation{
+		Type:  OpSetData,
+		Path:  path,
+		Name:  key,
+		Value: value,
+	})
+}
+
+// DeleteData deletes a data value of the block at the path.
+func (r *Recorder) DeleteData(path BlockPath, key string) error {
+	return r.Apply(Operation{
+		Type: OpDeleteData,
+		Path: path,
+		Name: key,
+	})
+}
+
+// Delete is the recorded version of Delete().
+func (r *Recorder) Delete(expr string) (int, error) {
+	selectors, childSelectors, err := parseSelectorExpression(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	paths := matchingPaths(*r.doc, selectors, childSelectors)
+
+	// Delete in reverse document order so that the remaining paths stay
+	// valid.
+	for i := len(paths) - 1; i >= 0; i-- {
+		err := r.DeleteBlock(paths[i])
+		if err != nil {
+			return len(paths) - 1 - i, err
+		}
+	}
+
+	return len(paths), nil
+}
+
+// Set is the recorded version of Set().
+func (r *Recorder) Set(expr string, value string) (int, error) {
+	ve, name, err := parseSetExpression(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ve.Selectors) == 0 {
+		err := r.SetAttribute(nil, name, value)
+		if err != nil {
+			return 0, err
+		}
+
+		return 1, nil
+	}
+
+	paths := matchingPaths(*r.doc, ve.Selectors, ve.ChildSelectors)
+
+	for i, p := range paths {
+		if ve.ValueKind == ValueKindAttributes {
+			err = r.SetAttribute(p, name, value)
+		} else {
+			err = r.SetData(p, name, value)
+		}
+
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(paths), nil
+}
+
+// Update is the recorded version of Update(). The changes that fn makes are
+// recorded as attribute, data and block operations.
+func (r *Recorder) Update(expr string, fn func(b *Block)) (int, error) {
+	var n int
+
+	err := r.alterDocument(func(doc *Document) error {
+		var err error
+
+		n, err = Update(doc, expr, fn)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// InsertBefore is the recorded version of InsertBefore(). The blocks are
+// inserted into the list of the given kind in the parent block, or in the
+// document if the parent path is empty.
+func (r *Recorder) InsertBefore(
+	parent BlockPath, kind BlockKind, selector BlockMatcher, blocks ...Block,
+) error {
+	return r.alterList(parent, kind, func(list []Block) []Block {
+		return InsertBefore(list, selector, blocks...)
+	})
+}
+
+// InsertAfter is the recorded version of InsertAfter(), see InsertBefore()
+// for how the list is selected.
+func (r *Recorder) InsertAfter(
+	parent BlockPath, kind BlockKind, selector BlockMatcher, blocks ...Block,
+) error {
+	return r.alterList(parent, kind, func(list []Block) []Block {
+		return InsertAfter(list, selector, blocks...)
+	})
+}
+
+// MoveBlock is the recorded version of MoveBlock(), see InsertBefore() for
+// how the list is selected. The move is recorded as a delete and an insert.
+func (r *Recorder) MoveBlock(
+	parent BlockPath, kind BlockKind, selector BlockMatcher, to BlockPosition,
+) (bool, error) {
+	var moved bool
+
+	err := r.alterList(parent, kind, func(list []Block) []Block {
+		list, moved = MoveBlock(list, selector, to)
+
+		return list
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return moved, nil
+}
+
+// MergeBlocks is the recorded version of MergeBlocks(), see InsertBefore()
+// for how the list is selected.
+func (r *Recorder) MergeBlocks(
+	parent BlockPath, kind BlockKind, incoming []Block, key KeyFunc,
+	strategy MergeStrategy, options ...MergeOption,
+) (MergeReport, error) {
+	var report MergeReport
+
+	err := r.alterList(parent, kind, func(list []Block) []Block {
+		var merged []Block
+
+		merged, report = MergeBlocks(list, incoming, key, strategy, options...)
+
+		return merged
+	})
+	if err != nil {
+		return MergeReport{}, err
+	}
+
+	return report, nil
+}
+
+// DropBlocksDeep is the recorded version of DropBlocksDeep().
+func (r *Recorder) DropBlocksDeep(
+	selector BlockMatcher, options ...DeepOption,
+) (int, error) {
+	var n int
+
+	err := r.alterDocument(func(doc *Document) error {
+		n = DropBlocksDeep(doc, selector, options...)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// AlterBlocksDeep is the recorded version of AlterBlocksDeep().
+func (r *Recorder) AlterBlocksDeep(
+	selector BlockMatcher, fn func(*Block), options ...DeepOption,
+) (int, error) {
+	var n int
+
+	err := r.alterDocument(func(doc *Document) error {
+		n = AlterBlocksDeep(doc, selector, fn, options...)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// alterDocument calls fn with a copy of the document, and records the changes
+// that fn made to the blocks of the copy.
+func (r *Recorder) alterDocument(fn func(doc *Document) error) error {
+	before := r.doc.Clone()
+	after := r.doc.Clone()
+
+	err := fn(&after)
+	if err != nil {
+		return err
+	}
+
+	for _, kind := range traversalOrder {
+		err := r.recordList(nil, kind,
+			documentBlocks(before, kind), documentBlocks(after, kind))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// alterList calls fn with a copy of the list of the kind in the parent block,
+// or in the document if the parent path is empty, and records the changes that
+// fn made to the list.
+func (r *Recorder) alterList(
+	parent BlockPath, kind BlockKind, fn func(list []Block) []Block,
+) error {
+	var list []Block
+
+	if len(parent) == 0 {
+		list = documentBlocks(*r.doc, kind)
+	} else {
+		b, ok := parent.Get(*r.doc)
+		if !ok {
+			return fmt.Errorf("no block at %s", parent)
+		}
+
+		list = childBlocks(b, kind)
+	}
+
+	before := cloneBlocks(list)
+
+	return r.recordList(parent, kind, before, fn(cloneBlocks(list)))
+}
+
+// recordList records the operations that turn the list of the kind in the
+// parent block from before into after. Blocks that are unchanged are found
+// using the longest common subsequence of the lists, and the changed blocks
+// in between are updated in place, deleted or inserted.
+func (r *Recorder) recordList(
+	parent BlockPath, kind BlockKind, before []Block, after []Block,
+) error {
+	var pos, bi, ai int
+
+	path := func() BlockPath {
+		return append(slices.Clip(parent), PathStep{Kind: kind, Index: pos})
+	}
+
+	flush := func(bEnd int, aEnd int) error {
+		for ; bi < bEnd && ai < aEnd; bi, ai, pos = bi+1, ai+1, pos+1 {
+			err := r.recordBlock(path(), before[bi], after[ai])
+			if err != nil {
+				return err
+			}
+		}
+
+		for ; bi < bEnd; bi++ {
+			err := r.DeleteBlock(path())
+			if err != nil {
+				return err
+			}
+		}
+
+		for ; ai < aEnd; ai, pos = ai+1, pos+1 {
+			err := r.InsertBlock(path(), after[ai])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, m := range commonBlocks(before, after) {
+		err := flush(m[0], m[1])
+		if err != nil {
+			return err
+		}
+
+		bi, ai, pos = bi+1, ai+1, pos+1
+	}
+
+	return flush(len(before), len(after))
+}
+
+// recordBlock records the operations that change the block at the path from
+// before into after.
+func (r *Recorder) recordBlock(path BlockPath, before Block, after Block) error {
+	for _, name := range blockAttributes {
+		value := getBlockAttribute(after, string(name))
+		if getBlockAttribute(before, string(name)) == value {
+			continue
+		}
+
+		err := r.SetAttribute(path, string(name), value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(before.Data)) {
+		if _, ok := after.Data[key]; ok {
+			continue
+		}
+
+		err := r.DeleteData(path, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(after.Data)) {
+		value := after.Data[key]
+
+		if v, ok := before.Data[key]; ok && v == value {
+			continue
+		}
+
+		err := r.SetData(path, key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, kind := range traversalOrder {
+		err := r.recordList(path, kind,
+			childBlocks(before, kind), childBlocks(after, kind))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// blockAttributes lists the block attributes in the order of the Block
+// fields.
+var blockAttributes = []blockAttributeKey{
+	blockAttrID, blockAttrUUID, blockAttrURI, blockAttrURL, blockAttrType,
+	blockAttrTitle, blockAttrRel, blockAttrRole, blockAttrName,
+	blockAttrValue, blockAttrContentType, blockAttrSensitivity,
+}
+
+// commonBlocks returns the index pairs of the longest common subsequence of
+// equal blocks in the lists.
+func commonBlocks(a []Block, b []Block) [][2]int {
+	// lengths[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	lengths := make([][]int, len(a)+1)
+
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if blocksEqual(a[i], b[j]) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case blocksEqual(a[i], b[j]):
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return pairs
+}
+
+// matchingPaths returns the paths of the blocks matching the selector chain.
+func matchingPaths(
+	doc Document, selectors []BlockSelector, childSelectors []BlockSelector,
+) []BlockPath {
+	var paths []BlockPath
+
+	root := documentBlocks(doc, selectors[0].Kind)
+
+	for p, b := range selectBlocks(root, selectors, true) {
+		if !hasMatchingChildren(b, childSelectors) {
+			continue
+		}
+
+		paths = append(paths, p)
+	}
+
+	return paths
+}
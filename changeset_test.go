@@ -0,0 +1,341 @@
+package newsdoc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func mustPath(t *testing.T, s string) newsdoc.BlockPath {
+	t.Helper()
+
+	p, err := newsdoc.ParseBlockPath(s)
+	test.Mustf(t, err, "parse path %q", s)
+
+	return p
+}
+
+func recordPlanningEdits(t *testing.T, doc *newsdoc.Document) newsdoc.Changeset {
+	t.Helper()
+
+	rec := newsdoc.NewRecorder(doc)
+
+	test.Mustf(t, rec.SetAttribute(nil, "title", "Renamed planning"),
+		"set document title")
+	test.Mustf(t, rec.InsertBlock(mustPath(t, "meta[5].links[0]"), newsdoc.Block{
+		Type: "core/article",
+		Rel:  "deliverable",
+		UUID: "4d3b5c1e-9a2f-4e7b-8c6d-1f0e2a3b4c5d",
+	}), "insert deliverable")
+	test.Mustf(t, rec.SetData(mustPath(t, "meta[0]"), "public", "false"),
+		"set public")
+	test.Mustf(t, rec.SetData(mustPath(t, "meta[0]"), "priority", "high"),
+		"set new data key")
+	test.Mustf(t, rec.DeleteData(mustPath(t, "meta[0]"), "tentative"),
+		"delete data key")
+	test.Mustf(t, rec.DeleteBlock(mustPath(t, "meta[2]")),
+		"delete description")
+
+	n, err := rec.Set(".meta(type='core/assignment')@{title}", "Renamed")
+	test.Mustf(t, err, "set assignment title")
+
+	if n != 1 {
+		t.Fatalf("expected one assignment to be renamed, got %d", n)
+	}
+
+	n, err = rec.Delete(".links")
+	test.Mustf(t, err, "delete links")
+
+	if n != 2 {
+		t.Fatalf("expected two links to be deleted, got %d", n)
+	}
+
+	return rec.Changeset()
+}
+
+func TestChangeset(t *testing.T) {
+	original := loadPlanningDocument(t)
+	edited := original.Clone()
+
+	cs := recordPlanningEdits(t, &edited)
+
+	test.AgainstGolden(t, test.Regenerate(), cs,
+		filepath.Join("testdata", t.Name(), "changeset.json"),
+		test.EquateEmpty{})
+
+	if len(edited.Links) != 0 {
+		t.Errorf("expected all links to be deleted, got %d", len(edited.Links))
+	}
+
+	data, err := json.Marshal(cs)
+	test.Mustf(t, err, "marshal changeset")
+
+	var decoded newsdoc.Changeset
+
+	err = json.Unmarshal(data, &decoded)
+	test.Mustf(t, err, "unmarshal changeset")
+
+	replayed := original.Clone()
+
+	err = decoded.Apply(&replayed)
+	test.Mustf(t, err, "replay changeset")
+
+	equateEmpty := test.EquateEmpty{}.CmpOpts()
+
+	test.EqualDiffWithOptionsf(t, edited, replayed, equateEmpty,
+		"replayed document must match the edited document")
+
+	undo, err := decoded.Invert()
+	test.Mustf(t, err, "invert changeset")
+
+	err = undo.Apply(&replayed)
+	test.Mustf(t, err, "apply inverted changeset")
+
+	test.EqualDiffWithOptionsf(t, original, replayed, equateEmpty,
+		"undone document must match the original")
+}
+
+func TestChangesetApplyErrors(t *testing.T) {
+	cases := map[string]newsdoc.Operation{
+		"missing block": {
+			Type: newsdoc.OpDeleteBlock,
+			Path: mustPath(t, "meta[10]"),
+		},
+		"missing parent": {
+			Type:  newsdoc.OpInsertBlock,
+			Path:  mustPath(t, "meta[10].links[0]"),
+			Block: &newsdoc.Block{Type: "core/article"},
+		},
+		"insert out of range": {
+			Type:  newsdoc.OpInsertBlock,
+			Path:  mustPath(t, "links[3]"),
+			Block: &newsdoc.Block{Type: "core/article"},
+		},
+		"insert without block": {
+			Type: newsdoc.OpInsertBlock,
+			Path: mustPath(t, "links[0]"),
+		},
+		"unknown attribute": {
+			Type:  newsdoc.OpSetAttribute,
+			Path:  mustPath(t, "meta[0]"),
+			Name:  "colour",
+			Value: "red",
+		},
+		"unknown operation": {
+			Type: "rename",
+			Path: mustPath(t, "meta[0]"),
+		},
+		"data without path": {
+			Type: newsdoc.OpSetData,
+			Name: "public",
+		},
+	}
+
+	for name, op := range cases {
+		t.Run(name, func(t *testing.T) {
+			doc := loadPlanningDocument(t)
+
+			err := op.Apply(&doc)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestChangesetInvertRequiresDeletedBlock(t *testing.T) {
+	cs := newsdoc.Changeset{
+		Operations: []newsdoc.Operation{
+			{Type: newsdoc.OpDeleteBlock, Path: mustPath(t, "meta[0]")},
+		},
+	}
+
+	_, err := cs.Invert()
+	if err == nil {
+		t.Fatal("expected an error when inverting a delete without a block")
+	}
+}
+
+func TestRecorderOperations(t *testing.T) {
+	assignment := newsdoc.BlocksWithType("core/assignment")
+	deliverable := newsdoc.Block{
+		Type: "core/article",
+		Rel:  "deliverable",
+		UUID: "4d3b5c1e-9a2f-4e7b-8c6d-1f0e2a3b4c5d",
+	}
+
+	cases := map[string]struct {
+		// Record makes the changes with the recorder.
+		Record func(t *testing.T, rec *newsdoc.Recorder) error
+		// Direct makes the same changes without recording them.
+		Direct func(t *testing.T, doc *newsdoc.Document)
+	}{
+		"update": {
+			Record: func(_ *testing.T, rec *newsdoc.Recorder) error {
+				_, err := rec.Update(".meta(type='core/assignment')", renameAssignment)
+
+				return err
+			},
+			Direct: func(t *testing.T, doc *newsdoc.Document) {
+				_, err := newsdoc.Update(doc, ".meta(type='core/assignment')", renameAssignment)
+				test.Mustf(t, err, "update")
+			},
+		},
+		"insert_before": {
+			Record: func(t *testing.T, rec *newsdoc.Recorder) error {
+				return rec.InsertBefore(mustPath(t, "meta[5]"), newsdoc.BlockKindLinks,
+					newsdoc.BlocksWithRel("deliverable"), deliverable)
+			},
+			Direct: func(_ *testing.T, doc *newsdoc.Document) {
+				doc.Meta[5].Links = newsdoc.InsertBefore(doc.Meta[5].Links,
+					newsdoc.BlocksWithRel("deliverable"), deliverable)
+			},
+		},
+		"insert_after": {
+			Record: func(_ *testing.T, rec *newsdoc.Recorder) error {
+				return rec.InsertAfter(nil, newsdoc.BlockKindMeta,
+					newsdoc.BlocksWithType("tt/slugline"),
+					newsdoc.Block{Type: "core/note"}, newsdoc.Block{Type: "core/note"})
+			},
+			Direct: func(_ *testing.T, doc *newsdoc.Document) {
+				doc.Meta = newsdoc.InsertAfter(doc.Meta,
+					newsdoc.BlocksWithType("tt/slugline"),
+					newsdoc.Block{Type: "core/note"}, newsdoc.Block{Type: "core/note"})
+			},
+		},
+		"move_block": {
+			Record: func(_ *testing.T, rec *newsdoc.Recorder) error {
+				moved, err := rec.MoveBlock(nil, newsdoc.BlockKindMeta,
+					assignment, newsdoc.PositionFirst())
+				if !moved {
+					return errors.New("the assignment wasn't moved")
+				}
+
+				return err
+			},
+			Direct: func(_ *testing.T, doc *newsdoc.Document) {
+				doc.Meta, _ = newsdoc.MoveBlock(doc.Meta, assignment, newsdoc.PositionFirst())
+			},
+		},
+		"merge_blocks": {
+			Record: func(_ *testing.T, rec *newsdoc.Recorder) error {
+				_, err := rec.MergeBlocks(nil, newsdoc.BlockKindLinks,
+					mergedLinks(), newsdoc.BlockKeyTypeRel, newsdoc.MergeReplace,
+					newsdoc.MergeRemoveMissing(nil))
+
+				return err
+			},
+			Direct: func(_ *testing.T, doc *newsdoc.Document) {
+				doc.Links, _ = newsdoc.MergeBlocks(doc.Links,
+					mergedLinks(), newsdoc.BlockKeyTypeRel, newsdoc.MergeReplace,
+					newsdoc.MergeRemoveMissing(nil))
+			},
+		},
+		"drop_blocks_deep": {
+			Record: func(_ *testing.T, rec *newsdoc.Recorder) error {
+				_, err := rec.DropBlocksDeep(newsdoc.BlocksWithRel("deliverable"))
+
+				return err
+			},
+			Direct: func(_ *testing.T, doc *newsdoc.Document) {
+				newsdoc.DropBlocksDeep(doc, newsdoc.BlocksWithRel("deliverable"))
+			},
+		},
+		"alter_blocks_deep": {
+			Record: func(_ *testing.T, rec *newsdoc.Recorder) error {
+				_, err := rec.AlterBlocksDeep(newsdoc.BlocksWithType("core/assignment-type"),
+					addPublicFlag)
+
+				return err
+			},
+			Direct: func(_ *testing.T, doc *newsdoc.Document) {
+				newsdoc.AlterBlocksDeep(doc, newsdoc.BlocksWithType("core/assignment-type"),
+					addPublicFlag)
+			},
+		},
+	}
+
+	equateEmpty := test.EquateEmpty{}.CmpOpts()
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			original := loadPlanningDocument(t)
+
+			want := original.Clone()
+
+			tc.Direct(t, &want)
+
+			edited := original.Clone()
+			rec := newsdoc.NewRecorder(&edited)
+
+			test.Mustf(t, tc.Record(t, rec), "record changes")
+
+			test.EqualDiffWithOptionsf(t, want, edited, equateEmpty,
+				"the recorder must make the same changes")
+
+			cs := rec.Changeset()
+
+			if len(cs.Operations) == 0 {
+				t.Fatal("expected operations to be recorded")
+			}
+
+			replayed := original.Clone()
+
+			test.Mustf(t, cs.Apply(&replayed), "replay changeset")
+
+			test.EqualDiffWithOptionsf(t, want, replayed, equateEmpty,
+				"replayed document must match the edited document")
+
+			undo, err := cs.Invert()
+			test.Mustf(t, err, "invert changeset")
+
+			test.Mustf(t, undo.Apply(&replayed), "apply inverted changeset")
+
+			test.EqualDiffWithOptionsf(t, original, replayed, equateEmpty,
+				"undone document must match the original")
+		})
+	}
+}
+
+func renameAssignment(b *newsdoc.Block) {
+	b.Title = "Renamed"
+	b.Data["public"] = "false"
+
+	delete(b.Data, "full_day")
+
+	b.Links = b.Links[1:]
+}
+
+func addPublicFlag(b *newsdoc.Block) {
+	b.Value = "picture"
+	b.Data = newsdoc.DataMap{"public": "true"}
+}
+
+func mergedLinks() []newsdoc.Block {
+	return []newsdoc.Block{
+		{Type: "core/section", Rel: "section", Title: "Sports"},
+		{Type: "core/story", Rel: "story", UUID: "5a2c1e3b-8f4d-4c6a-9b7e-2d1f0a3c4b5e"},
+	}
+}
+
+func TestChangesetInvertRequiresPreviousAttribute(t *testing.T) {
+	cs := newsdoc.Changeset{
+		Operations: []newsdoc.Operation{
+			{
+				Type:  newsdoc.OpSetAttribute,
+				Path:  mustPath(t, "meta[0]"),
+				Name:  "title",
+				Value: "Renamed",
+			},
+		},
+	}
+
+	_, err := cs.Invert()
+	if err == nil {
+		t.Fatal("expected an error when inverting an attribute change without the previous value")
+	}
+}
@@ -0,0 +1,268 @@
+package newsdoc
+
+import "slices"
+
+// DeepOption is an option for the deep block operations.
+type DeepOption func(opts *deepOptions)
+
+type deepOptions struct {
+	kinds    []BlockKind
+	maxDepth int
+}
+
+// TraverseKinds restricts the deep block operations to the given kinds of
+// blocks, at all levels of the document. F.ex. TraverseKinds(BlockKindMeta)
+// will only visit meta blocks, and the meta blocks of meta blocks.
+func TraverseKinds(kinds ...BlockKind) DeepOption {
+	return func(opts *deepOptions) {
+		opts.kinds = kinds
+	}
+}
+
+// TraverseMaxDepth restricts the deep block operations to blocks at most depth
+// levels down, where the top level blocks of the document are at depth 1.
+func TraverseMaxDepth(depth int) DeepOption {
+	return func(opts *deepOptions) {
+		opts.maxDepth = depth
+	}
+}
+
+// traversalOrder is the order in which block kinds are visited, the same order
+// as the fields of a Document.
+var traversalOrder = []BlockKind{
+	BlockKindContent, BlockKindMeta, BlockKindLinks,
+}
+
+func newDeepOptions(options []DeepOption) deepOptions {
+	opts := deepOptions{
+		kinds: traversalOrder,
+	}
+
+	for _, o := range options {
+		o(&opts)
+	}
+
+	return opts
+}
+
+func (opts deepOptions) traverses(kind BlockKind) bool {
+	return slices.Contains(opts.kinds, kind)
+}
+
+// BlockAtPath is a block together with its location in a document.
+type BlockAtPath struct {
+	Path  BlockPath
+	Block Block
+}
+
+// FirstBlockDeep returns the first block anywhere in the document that matches
+// the selector. Blocks are visited depth-first, parents before their children,
+// and content before meta and links.
+func FirstBlockDeep(
+	doc Document, selector BlockMatcher, options ...DeepOption,
+) (BlockAtPath, bool) {
+	var (
+		found BlockAtPath
+		ok    bool
+	)
+
+	inspectDocument(doc, newDeepOptions(options), func(path BlockPath, b *Block) walkAction {
+		if !selector.Match(*b) {
+			return walkContinue
+		}
+
+		found = BlockAtPath{Path: path, Block: *b}
+		ok = true
+
+		return walkStop
+	})
+
+	return found, ok
+}
+
+// AllBlocksDeep returns all blocks anywhere in the document that match the
+// selector, together with their paths. Blocks are visited depth-first,
+// parents before their children, and content before meta and links.
+func AllBlocksDeep(
+	doc Document, selector BlockMatcher, options ...DeepOption,
+) []BlockAtPath {
+	var res []BlockAtPath
+
+	inspectDocument(doc, newDeepOptions(options), func(path BlockPath, b *Block) walkAction {
+		if selector.Match(*b) {
+			res = append(res, BlockAtPath{Path: path, Block: *b})
+		}
+
+		return walkContinue
+	})
+
+	return res
+}
+
+// DropBlocksDeep removes all blocks anywhere in the document that match the
+// selector, including their descendants. Returns the number of removed
+// blocks, not counting descendants.
+func DropBlocksDeep(
+	doc *Document, selector BlockMatcher, options ...DeepOption,
+) int {
+	var n int
+
+	walkDocument(doc, newDeepOptions(options), func(_ BlockPath, b *Block) walkAction {
+		if !selector.Match(*b) {
+			return walkContinue
+		}
+
+		n++
+
+		return walkDrop
+	})
+
+	return n
+}
+
+// AlterBlocksDeep calls fn for each block anywhere in the document that
+// matches the selector. The children of a block are visited after fn has been
+// called for the block. Returns the number of altered blocks.
+func AlterBlocksDeep(
+	doc *Document, selector BlockMatcher, fn func(*Block),
+	options ...DeepOption,
+) int {
+	var n int
+
+	walkDocument(doc, newDeepOptions(options), func(_ BlockPath, b *Block) walkAction {
+		if !selector.Match(*b) {
+			return walkContinue
+		}
+
+		n++
+
+		fn(b)
+
+		return walkContinue
+	})
+
+	return n
+}
+
+type walkAction int
+
+const (
+	// walkContinue visits the children of the block.
+	walkContinue walkAction = iota
+	// walkSkip doesn't visit the children of the block.
+	walkSkip
+	// walkDrop removes the block from its parent.
+	walkDrop
+	// walkStop stops the walk.
+	walkStop
+)
+
+// walkDocument visits the blocks of the document depth-first. The block
+// passed to fn can be modified, and removed by returning walkDrop. The paths
+// passed to fn are the locations of the blocks before any blocks were
+// removed.
+func walkDocument(
+	doc *Document, opts deepOptions,
+	fn func(path BlockPath, b *Block) walkAction,
+) {
+	w := blockWalker{opts: opts, fn: fn, mutable: true}
+
+	for _, kind := range traversalOrder {
+		if !opts.traverses(kind) || w.stopped {
+			continue
+		}
+
+		blocks := w.walk(documentBlocks(*doc, kind), kind, nil)
+
+		setDocumentBlocks(doc, kind, blocks)
+	}
+}
+
+// inspectDocument visits the blocks of the document depth-first without
+// modifying it. The fn must not modify the blocks or return walkDrop.
+func inspectDocument(
+	doc Document, opts deepOptions,
+	fn func(path BlockPath, b *Block) walkAction,
+) {
+	w := blockWalker{opts: opts, fn: fn}
+
+	for _, kind := range traversalOrder {
+		if !opts.traverses(kind) || w.stopped {
+			continue
+		}
+
+		w.walk(documentBlocks(doc, kind), kind, nil)
+	}
+}
+
+type blockWalker struct {
+	opts    deepOptions
+	fn      func(path BlockPath, b *Block) walkAction
+	mutable bool
+	stopped bool
+}
+
+func (w *blockWalker) walk(blocks []Block, kind BlockKind, parent BlockPath) []Block {
+	var keep int
+
+	depth := len(parent) + 1
+
+	for i := range blocks {
+		if w.stopped {
+			if !w.mutable {
+				break
+			}
+
+			blocks[keep] = blocks[i]
+			keep++
+
+			continue
+		}
+
+		path := append(slices.Clip(parent), PathStep{
+			Kind:  kind,
+			Index: i,
+		})
+
+		switch w.fn(path, &blocks[i]) {
+		case walkDrop:
+			continue
+		case walkStop:
+			w.stopped = true
+		case walkContinue:
+			if w.opts.maxDepth > 0 && depth >= w.opts.maxDepth {
+				break
+			}
+
+			w.walkChildren(&blocks[i], path)
+		case walkSkip:
+		}
+
+		if w.mutable {
+			blocks[keep] = blocks[i]
+			keep++
+		}
+	}
+
+	if !w.mutable || keep == len(blocks) {
+		return blocks
+	}
+
+	clear(blocks[keep:])
+
+	return blocks[:keep]
+}
+
+func (w *blockWalker) walkChildren(b *Block, path BlockPath) {
+	for _, k := range traversalOrder {
+		if !w.opts.traverses(k) || w.stopped {
+			continue
+		}
+
+		children := w.walk(childBlocks(*b, k), k, path)
+
+		if w.mutable {
+			setChildBlocks(b, k, children)
+		}
+	}
+}
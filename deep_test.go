@@ -0,0 +1,166 @@
+package newsdoc_test
+
+import (
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+const internal = "internal"
+
+func deepDocument() newsdoc.Document {
+	return newsdoc.Document{
+		Content: []newsdoc.Block{
+			{Type: coreText, Value: "public"},
+			{Type: coreText, Value: "secret", Sensitivity: internal},
+			{
+				Type: "core/factbox",
+				Content: []newsdoc.Block{
+					{Type: coreText, Value: "fact", Sensitivity: internal},
+					{Type: coreText, Value: "other fact"},
+				},
+			},
+		},
+		Meta: []newsdoc.Block{
+			{
+				Type: "core/assignment",
+				Meta: []newsdoc.Block{
+					{Type: "core/note", Sensitivity: internal},
+				},
+				Links: []newsdoc.Block{
+					{Rel: "deliverable", Sensitivity: internal},
+				},
+			},
+		},
+		Links: []newsdoc.Block{
+			{Rel: "author", Sensitivity: internal},
+			{Rel: "section"},
+		},
+	}
+}
+
+func pathStrings(matches []newsdoc.BlockAtPath) []string {
+	paths := make([]string, len(matches))
+
+	for i, m := range matches {
+		paths[i] = m.Path.String()
+	}
+
+	return paths
+}
+
+func TestAllBlocksDeep(t *testing.T) {
+	doc := deepDocument()
+
+	matches := newsdoc.AllBlocksDeep(doc,
+		newsdoc.BlocksWithSensitivity(internal))
+
+	want := []string{
+		"content[1]",
+		"content[2].content[0]",
+		"meta[0].meta[0]",
+		"meta[0].links[0]",
+		"links[0]",
+	}
+
+	test.EqualDiffWithOptionsf(t, want, pathStrings(matches), nil,
+		"paths of internal blocks")
+
+	for _, m := range matches {
+		b, ok := m.Path.Get(doc)
+		if !ok || b.Sensitivity != internal {
+			t.Errorf("path %s doesn't resolve to the matched block", m.Path)
+		}
+	}
+}
+
+func TestAllBlocksDeepOptions(t *testing.T) {
+	doc := deepDocument()
+	internalBlocks := newsdoc.BlocksWithSensitivity(internal)
+
+	matches := newsdoc.AllBlocksDeep(doc, internalBlocks,
+		newsdoc.TraverseKinds(newsdoc.BlockKindMeta))
+
+	test.EqualDiffWithOptionsf(t,
+		[]string{"meta[0].meta[0]"}, pathStrings(matches), nil,
+		"paths when only traversing meta")
+
+	matches = newsdoc.AllBlocksDeep(doc, internalBlocks,
+		newsdoc.TraverseMaxDepth(1))
+
+	test.EqualDiffWithOptionsf(t,
+		[]string{"content[1]", "links[0]"}, pathStrings(matches), nil,
+		"paths of top level blocks")
+}
+
+func TestFirstBlockDeep(t *testing.T) {
+	doc := deepDocument()
+
+	m, ok := newsdoc.FirstBlockDeep(doc, newsdoc.BlocksWithValue("other fact"))
+	if !ok {
+		t.Fatal("expected to find the block")
+	}
+
+	if m.Path.String() != "content[2].content[1]" {
+		t.Errorf("unexpected path %s", m.Path)
+	}
+
+	_, ok = newsdoc.FirstBlockDeep(doc, newsdoc.BlocksWithValue("nonesuch"))
+	if ok {
+		t.Error("should not find a non-existent block")
+	}
+}
+
+func TestDropBlocksDeep(t *testing.T) {
+	doc := deepDocument()
+
+	n := newsdoc.DropBlocksDeep(&doc, newsdoc.BlocksWithSensitivity(internal))
+	if n != 5 {
+		t.Errorf("expected 5 dropped blocks, got %d", n)
+	}
+
+	remaining := newsdoc.AllBlocksDeep(doc, newsdoc.BlocksWithSensitivity(internal))
+	if len(remaining) != 0 {
+		t.Errorf("expected no internal blocks, found %v", pathStrings(remaining))
+	}
+
+	if len(doc.Content) != 2 || doc.Content[1].Content[0].Value != "other fact" {
+		t.Errorf("expected non-matching blocks to remain in order")
+	}
+
+	if len(doc.Links) != 1 || doc.Links[0].Rel != "section" {
+		t.Errorf("expected the section link to remain")
+	}
+}
+
+func TestDropBlocksDeepKinds(t *testing.T) {
+	doc := deepDocument()
+
+	n := newsdoc.DropBlocksDeep(&doc, newsdoc.BlocksWithSensitivity(internal),
+		newsdoc.TraverseKinds(newsdoc.BlockKindContent))
+	if n != 2 {
+		t.Errorf("expected 2 dropped blocks, got %d", n)
+	}
+
+	if len(doc.Links) != 2 {
+		t.Errorf("links should not have been traversed")
+	}
+}
+
+func TestAlterBlocksDeep(t *testing.T) {
+	doc := deepDocument()
+
+	n := newsdoc.AlterBlocksDeep(&doc, newsdoc.BlocksWithType(coreText),
+		func(b *newsdoc.Block) {
+			b.Role = altered
+		})
+	if n != 4 {
+		t.Errorf("expected 4 altered blocks, got %d", n)
+	}
+
+	res := newsdoc.AllBlocksDeep(doc, newsdoc.BlocksWithRole(altered))
+	if len(res) != 4 {
+		t.Errorf("expected 4 blocks with the altered role, got %d", len(res))
+	}
+}
@@ -0,0 +1,317 @@
+package newsdoc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Explanation is a trace of the evaluation of a ValueExtractor against a
+// document, see ValueExtractor.Explain().
+type Explanation struct {
+	// Steps has one entry for each selector in the selector chain.
+	Steps []ExplainStep
+	// Rows has one entry for each block that was matched by the selector
+	// chain, or a single entry for the document if the extractor targets
+	// document attributes.
+	Rows []ExplainRow
+}
+
+// ExplainStep is the trace of a single selector step.
+type ExplainStep struct {
+	// Selector is the selector in the expression syntax.
+	Selector string
+	// Candidates are the blocks that the selector was evaluated against.
+	Candidates []ExplainCandidate `json:",omitempty"`
+}
+
+// Matched returns the number of candidates that matched the selector.
+func (s ExplainStep) Matched() int {
+	var n int
+
+	for _, c := range s.Candidates {
+		if c.Matched {
+			n++
+		}
+	}
+
+	return n
+}
+
+// ExplainCandidate is a block that a selector was evaluated against.
+type ExplainCandidate struct {
+	Path    BlockPath
+	Type    string `json:",omitempty"`
+	Matched bool
+	// FailedFilters are the filter conditions that failed for the block,
+	// in the selector syntax.
+	FailedFilters []string `json:",omitempty"`
+}
+
+// ExplainDropReason describes why a matched block didn't produce a row.
+type ExplainDropReason string
+
+const (
+	// ExplainDropChildSelectors is used when the block didn't have any
+	// descendants matching the child selectors.
+	ExplainDropChildSelectors ExplainDropReason = "child-selectors"
+	// ExplainDropMissingValue is used when a required value was missing.
+	ExplainDropMissingValue ExplainDropReason = "missing-value"
+	// ExplainDropNoValues is used when all values are optional and none
+	// of them were found.
+	ExplainDropNoValues ExplainDropReason = "no-values"
+)
+
+// ExplainRow is the trace of the value extraction from a matched block.
+type ExplainRow struct {
+	// Path is the location of the block, empty for document attributes.
+	Path BlockPath `json:",omitempty"`
+	// Included is true if the block produced a row.
+	Included bool
+	// Dropped is the reason that the block didn't produce a row.
+	Dropped ExplainDropReason `json:",omitempty"`
+	// MissingValue is the value spec that caused the row to be dropped.
+	MissingValue *ValueSpec `json:",omitempty"`
+	// ChildSteps is the trace of the child selector chain, evaluated
+	// against the descendants of the block.
+	ChildSteps []ExplainStep `json:",omitempty"`
+}
+
+// Explain evaluates the extractor against the document and returns a trace of
+// the evaluation. This is intended for debugging expressions that don't return
+// the expected values, use Collect() for actual value extraction.
+func (ve *ValueExtractor) Explain(doc Document) Explanation {
+	var exp Explanation
+
+	if len(ve.Selectors) == 0 {
+		row := explainValues(ve.Values, func(v ValueSpec) string {
+			return getDocumentAttribute(doc, v.Name)
+		})
+
+		exp.Rows = []ExplainRow{row}
+
+		return exp
+	}
+
+	root := []explainedBlock{{}}
+	rootBlocks := func(_ Block, kind BlockKind) []Block {
+		return documentBlocks(doc, kind)
+	}
+
+	steps, matches := explainChain(root, ve.Selectors, rootBlocks)
+
+	exp.Steps = steps
+
+	for _, m := range matches {
+		exp.Rows = append(exp.Rows, ve.explainRow(m))
+	}
+
+	return exp
+}
+
+func (ve *ValueExtractor) explainRow(m explainedBlock) ExplainRow {
+	row := ExplainRow{Path: m.path}
+
+	if len(ve.ChildSelectors) > 0 {
+		steps, children := explainChain(
+			[]explainedBlock{m}, ve.ChildSelectors, childBlocks)
+
+		row.ChildSteps = steps
+
+		if len(children) == 0 {
+			row.Dropped = ExplainDropChildSelectors
+
+			return row
+		}
+	}
+
+	if ve.ValueKind == ValueKindBlock {
+		row.Included = true
+
+		return row
+	}
+
+	values := explainValues(ve.Values, func(v ValueSpec) string {
+		return ve.blockValue(m.block, v)
+	})
+
+	values.Path = row.Path
+	values.ChildSteps = row.ChildSteps
+
+	return values
+}
+
+// explainValues checks the values like Collect() does: a row is dropped if a
+// required value is missing, or if no value was found at all.
+func explainValues(specs []ValueSpec, value func(v ValueSpec) string) ExplainRow {
+	var (
+		row   ExplainRow
+		found bool
+	)
+
+	for _, v := range specs {
+		if value(v) != "" {
+			found = true
+
+			continue
+		}
+
+		if v.Optional {
+			continue
+		}
+
+		row.Dropped = ExplainDropMissingValue
+		row.MissingValue = &v
+
+		return row
+	}
+
+	if !found {
+		row.Dropped = ExplainDropNoValues
+
+		return row
+	}
+
+	row.Included = true
+
+	return row
+}
+
+// blockValue returns the value for a value spec from the block.
+func (ve *ValueExtractor) blockValue(b Block, v ValueSpec) string {
+	source := v.Source
+
+	if ve.ValueKind == ValueKindAttributes {
+		source = ValueSourceAttributes
+	}
+
+	if source == ValueSourceAttributes {
+		return getBlockAttribute(b, v.Name)
+	}
+
+	return getBlockData(b, v.Name)
+}
+
+type explainedBlock struct {
+	path  BlockPath
+	block Block
+}
+
+// explainChain evaluates a selector chain, starting with the children of the
+// given parents, and returns a trace of each step together with the final
+// matches.
+func explainChain(
+	parents []explainedBlock, selectors []BlockSelector,
+	children func(b Block, kind BlockKind) []Block,
+) ([]ExplainStep, []explainedBlock) {
+	steps := make([]ExplainStep, 0, len(selectors))
+
+	for i, sel := range selectors {
+		step := ExplainStep{Selector: sel.String()}
+
+		var matched []explainedBlock
+
+		for _, p := range parents {
+			// Only the first step of the document chain uses the
+			// root accessor, the following steps navigate into
+			// child blocks.
+			list := childBlocks(p.block, sel.Kind)
+			if i == 0 {
+				list = children(p.block, sel.Kind)
+			}
+
+			for j, b := range list {
+				c := ExplainCandidate{
+					Path: append(slices.Clip(p.path), PathStep{
+						Kind:  sel.Kind,
+						Index: j,
+					}),
+					Type:          b.Type,
+					FailedFilters: sel.Filter.failedLeaves(b),
+				}
+
+				c.Matched = len(c.FailedFilters) == 0
+
+				step.Candidates = append(step.Candidates, c)
+
+				if c.Matched {
+					matched = append(matched, explainedBlock{
+						path:  c.Path,
+						block: b,
+					})
+				}
+			}
+		}
+
+		steps = append(steps, step)
+		parents = matched
+	}
+
+	return steps, parents
+}
+
+// failedLeaves returns the leaf conditions that caused the filter to not match
+// the block. Returns nil if the filter matches.
+func (fn *FilterNode) failedLeaves(b Block) []string {
+	if fn.Matches(b) {
+		return nil
+	}
+
+	switch fn.Op {
+	case FilterOpAnd, FilterOpOr:
+		var failed []string
+
+		for i := range fn.Children {
+			failed = append(failed, fn.Children[i].failedLeaves(b)...)
+		}
+
+		return failed
+	default:
+		return []string{fn.String()}
+	}
+}
+
+// String returns a human readable rendering of the explanation.
+func (e Explanation) String() string {
+	var sb strings.Builder
+
+	writeSteps(&sb, e.Steps, "")
+
+	for _, r := range e.Rows {
+		path := r.Path.String()
+		if path == "" {
+			path = "document"
+		}
+
+		switch {
+		case r.Included:
+			fmt.Fprintf(&sb, "row %s: included\n", path)
+		case r.MissingValue != nil:
+			fmt.Fprintf(&sb, "row %s: dropped, missing value %q\n",
+				path, r.MissingValue.Name)
+		default:
+			fmt.Fprintf(&sb, "row %s: dropped, %s\n", path, r.Dropped)
+		}
+
+		writeSteps(&sb, r.ChildSteps, "  #")
+	}
+
+	return sb.String()
+}
+
+func writeSteps(sb *strings.Builder, steps []ExplainStep, prefix string) {
+	for _, s := range steps {
+		fmt.Fprintf(sb, "%s%s: %d of %d candidates matched\n",
+			prefix, s.Selector, s.Matched(), len(s.Candidates))
+
+		for _, c := range s.Candidates {
+			if c.Matched {
+				continue
+			}
+
+			fmt.Fprintf(sb, "%s  %s (%s): failed %s\n",
+				prefix, c.Path, c.Type,
+				strings.Join(c.FailedFilters, ", "))
+		}
+	}
+}
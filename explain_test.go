@@ -0,0 +1,93 @@
+package newsdoc_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func TestValueExtractorExplain(t *testing.T) {
+	regenerate := test.Regenerate()
+	dataDir := filepath.Join("testdata", t.Name())
+
+	err := os.MkdirAll(dataDir, 0o770)
+	test.Mustf(t, err, "ensure testdata dir")
+
+	var doc newsdoc.Document
+
+	err = test.UnmarshalFile(
+		filepath.Join("testdata", "TestValueExtractor", "planning.json"),
+		&doc)
+	test.Mustf(t, err, "unmarshal document")
+
+	cases := map[string]string{
+		"typo_in_type":     ".meta(type='core/assigment').data{start_date}",
+		"missing_value":    ".meta(type='core/assignment').data{strat_date}",
+		"child_filtered":   "a=.meta(type='core/assignment')#.links(rel='deliverable' uuid='nope')",
+		"child_matched":    "a=.meta(type='core/assignment')#.links(rel='deliverable')",
+		"or_failed":        ".links(rel='event' (type='core/x' or type='core/y'))@{uuid}",
+		"nested":           ".meta(type='core/assignment').links(rel='deliverable')@{uuid}",
+		"document_missing": "@{title url}",
+		"no_values":        ".meta(type='core/assignment').data{strat_date? nope?}",
+	}
+
+	for name, exp := range cases {
+		t.Run(name, func(t *testing.T) {
+			ve, err := newsdoc.ValueExtractorFromString(exp)
+			test.Mustf(t, err, "parse expression %q", exp)
+
+			explanation := ve.Explain(doc)
+
+			// The explanation must agree with Collect() about the
+			// rows.
+			var included int
+
+			for _, r := range explanation.Rows {
+				if r.Included {
+					included++
+				}
+			}
+
+			rows := ve.Collect(doc)
+			if included != len(rows) {
+				t.Fatalf("explanation includes %d rows, Collect returned %d",
+					included, len(rows))
+			}
+
+			test.AgainstGolden(t, regenerate, explanation,
+				filepath.Join(dataDir, name+".json"))
+		})
+	}
+}
+
+func TestExplanationString(t *testing.T) {
+	doc := newsdoc.Document{
+		Meta: []newsdoc.Block{
+			{Type: "core/event", Data: newsdoc.DataMap{"date": "2024-01-01"}},
+			{Type: "core/note"},
+			{Type: "core/event"},
+		},
+	}
+
+	ve, err := newsdoc.ValueExtractorFromString(
+		".meta(type='core/event').data{date}")
+	test.Mustf(t, err, "parse expression")
+
+	text := ve.Explain(doc).String()
+
+	for _, want := range []string{
+		".meta(type='core/event'): 2 of 3 candidates matched",
+		"meta[1] (core/note): failed type='core/event'",
+		"row meta[0]: included",
+		`row meta[2]: dropped, missing value "date"`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected explanation to contain %q, got:\n%s",
+				want, text)
+		}
+	}
+}
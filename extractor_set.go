@@ -0,0 +1,230 @@
+package newsdoc
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ExtractorSet evaluates a set of named value extractors against a document.
+// Selector chains that share a common prefix are merged into a shared
+// evaluation plan, so that the filters of a selector only are evaluated once
+// per block regardless of how many extractors use it. Each block list is
+// walked once, and every block is checked against all the selectors of that
+// block kind at the same level of the plan.
+type ExtractorSet struct {
+	names    []string
+	document []namedExtractor
+	roots    []*planNode
+	groups   []planGroup
+}
+
+type namedExtractor struct {
+	name      string
+	extractor *ValueExtractor
+}
+
+// planNode is a selector in the evaluation plan. Extractors whose selector
+// chain ends at the node are listed as terminals.
+type planNode struct {
+	key       string
+	selector  BlockSelector
+	children  []*planNode
+	groups    []planGroup
+	terminals []namedExtractor
+}
+
+// planGroup is the plan nodes that select blocks of the same kind from the
+// same block list.
+type planGroup struct {
+	kind  BlockKind
+	nodes []*planNode
+}
+
+// NewExtractorSet compiles the named extractors into an ExtractorSet.
+func NewExtractorSet(extractors map[string]*ValueExtractor) *ExtractorSet {
+	es := ExtractorSet{
+		names: make([]string, 0, len(extractors)),
+	}
+
+	for name := range extractors {
+		es.names = append(es.names, name)
+	}
+
+	// Sort the names so that the plan is deterministic.
+	slices.Sort(es.names)
+
+	for _, name := range es.names {
+		ve := extractors[name]
+		ne := namedExtractor{name: name, extractor: ve}
+
+		if len(ve.Selectors) == 0 {
+			es.document = append(es.document, ne)
+
+			continue
+		}
+
+		nodes := &es.roots
+
+		var node *planNode
+
+		for _, sel := range ve.Selectors {
+			node = planChild(nodes, sel)
+			nodes = &node.children
+		}
+
+		node.terminals = append(node.terminals, ne)
+	}
+
+	es.groups = groupPlanNodes(es.roots)
+
+	return &es
+}
+
+// ExtractorSetFromStrings parses the named expressions and compiles them into
+// an ExtractorSet.
+func ExtractorSetFromStrings(expressions map[string]string) (*ExtractorSet, error) {
+	extractors := make(map[string]*ValueExtractor, len(expressions))
+
+	for name, exp := range expressions {
+		ve, err := ValueExtractorFromString(exp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression for %q: %w", name, err)
+		}
+
+		extractors[name] = ve
+	}
+
+	return NewExtractorSet(extractors), nil
+}
+
+// planChild returns the node for the selector from the list, adding it if it
+// doesn't exist yet.
+func planChild(nodes *[]*planNode, sel BlockSelector) *planNode {
+	key := sel.String()
+
+	for _, n := range *nodes {
+		if n.key == key {
+			return n
+		}
+	}
+
+	n := &planNode{
+		key:      key,
+		selector: sel,
+	}
+
+	*nodes = append(*nodes, n)
+
+	return n
+}
+
+// groupPlanNodes groups the nodes, and recursively their children, by block
+// kind.
+func groupPlanNodes(nodes []*planNode) []planGroup {
+	var groups []planGroup
+
+	for _, n := range nodes {
+		n.groups = groupPlanNodes(n.children)
+
+		idx := slices.IndexFunc(groups, func(g planGroup) bool {
+			return g.kind == n.selector.Kind
+		})
+		if idx == -1 {
+			groups = append(groups, planGroup{kind: n.selector.Kind})
+			idx = len(groups) - 1
+		}
+
+		groups[idx].nodes = append(groups[idx].nodes, n)
+	}
+
+	return groups
+}
+
+// Names returns the names of the extractors in the set in sorted order.
+func (es *ExtractorSet) Names() []string {
+	return slices.Clone(es.names)
+}
+
+// Collect evaluates all extractors against the document. The results are keyed
+// by extractor name and are the same as they would be from calling Collect() on
+// each extractor. Extractors that don't produce any results are omitted.
+func (es *ExtractorSet) Collect(
+	doc Document, options ...CollectOption,
+) map[string][]ExtractedItems {
+	var opts collectOptions
+
+	for _, o := range options {
+		o(&opts)
+	}
+
+	res := make(map[string][]ExtractedItems, len(es.names))
+
+	for _, ne := range es.document {
+		items := ne.extractor.Collect(doc)
+		if len(items) == 0 {
+			continue
+		}
+
+		res[ne.name] = items
+	}
+
+	for _, g := range es.groups {
+		g.evaluate(documentBlocks(doc, g.kind), nil, opts, res)
+	}
+
+	return res
+}
+
+// evaluate walks the blocks once and evaluates every node in the group
+// against each block.
+func (g planGroup) evaluate(
+	blocks []Block, path BlockPath, opts collectOptions,
+	res map[string][]ExtractedItems,
+) {
+	for i := range blocks {
+		for _, n := range g.nodes {
+			if !n.selector.Matches(blocks[i]) {
+				continue
+			}
+
+			n.evaluate(blocks[i], i, path, opts, res)
+		}
+	}
+}
+
+// evaluate collects the terminal extractors of the node for a matching block,
+// and evaluates the child groups against its child blocks.
+func (n *planNode) evaluate(
+	block Block, index int, path BlockPath, opts collectOptions,
+	res map[string][]ExtractedItems,
+) {
+	var p BlockPath
+
+	if opts.blockSource {
+		p = append(slices.Clip(path), PathStep{
+			Kind:  n.selector.Kind,
+			Index: index,
+		})
+	}
+
+	for _, t := range n.terminals {
+		e := t.extractor.extractBlock(block)
+		if len(e) == 0 {
+			continue
+		}
+
+		if opts.blockSource {
+			e.setSource(&BlockSource{
+				Path: p,
+				ID:   block.ID,
+				UUID: block.UUID,
+			})
+		}
+
+		res[t.name] = append(res[t.name], e)
+	}
+
+	for _, g := range n.groups {
+		g.evaluate(childBlocks(block, g.kind), p, opts, res)
+	}
+}
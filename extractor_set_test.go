@@ -0,0 +1,203 @@
+package newsdoc_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+var extractorSetExpressions = map[string]string{
+	"title":       "@{title}",
+	"planning":    ".meta(type='core/planning-item').data{start_date, date_tz?}",
+	"deliverable": ".meta(type='core/assignment').links(rel='deliverable')@{uuid}",
+	"nonesuch":    "block=.meta(type='core/assignment').links(rel='deliverable' data.nonesuch='value')",
+	"assignment":  "assignment=.meta(type='core/assignment')#.links(rel='deliverable' uuid='4f13347f-04b3-4f22-a992-9316d824b81f')",
+	"assign_id":   ".meta(type='core/assignment')@{id}#.links(rel='deliverable' uuid='4f13347f-04b3-4f22-a992-9316d824b81f')",
+	"combined":    ".meta(type='core/assignment')@{title}.data{start_date date_tz}",
+	"assign_type": ".meta(type='core/assignment').meta(type='core/assignment-type')@{value}",
+	"links":       ".links@{uuid rel title?}",
+	"section":     ".links(rel='section')@{uuid}",
+	"newsvalue":   ".meta(type='core/newsvalue')@{value}",
+	"items":       ".meta(type='example/collection').links(rel='item').data{date:date, tz=date_timezone?}",
+	"items_range": ".meta(type='example/collection').links(rel='item').data{start, end}",
+	"static_tz":   ".content(type='example/assumed-static-tz')@{value:date}",
+	"pit":         "pointy=.links(rel='point-in-time' type='example/pit'):interesting",
+}
+
+func TestExtractorSet(t *testing.T) {
+	dataDir := filepath.Join("testdata", "TestValueExtractor")
+
+	set, err := newsdoc.ExtractorSetFromStrings(extractorSetExpressions)
+	test.Mustf(t, err, "compile extractor set")
+
+	for _, name := range []string{"planning.json", "constructed.json"} {
+		t.Run(name, func(t *testing.T) {
+			var doc newsdoc.Document
+
+			err := test.UnmarshalFile(filepath.Join(dataDir, name), &doc)
+			test.Mustf(t, err, "unmarshal document")
+
+			want := make(map[string][]newsdoc.ExtractedItems)
+
+			for n, exp := range extractorSetExpressions {
+				ve, err := newsdoc.ValueExtractorFromString(exp)
+				test.Mustf(t, err, "parse expression %q", exp)
+
+				items := ve.Collect(doc)
+				if len(items) == 0 {
+					continue
+				}
+
+				want[n] = items
+			}
+
+			got := set.Collect(doc)
+
+			test.EqualDiffWithOptionsf(t, want, got, nil,
+				"set results must match individual Collect() calls")
+		})
+	}
+}
+
+func TestExtractorSetBlockSource(t *testing.T) {
+	var doc newsdoc.Document
+
+	err := test.UnmarshalFile(
+		filepath.Join("testdata", "TestValueExtractor", "planning.json"),
+		&doc)
+	test.Mustf(t, err, "unmarshal document")
+
+	set, err := newsdoc.ExtractorSetFromStrings(extractorSetExpressions)
+	test.Mustf(t, err, "compile extractor set")
+
+	want := make(map[string][]newsdoc.ExtractedItems)
+
+	for n, exp := range extractorSetExpressions {
+		ve, err := newsdoc.ValueExtractorFromString(exp)
+		test.Mustf(t, err, "parse expression %q", exp)
+
+		items := ve.Collect(doc, newsdoc.WithBlockSource())
+		if len(items) == 0 {
+			continue
+		}
+
+		want[n] = items
+	}
+
+	got := set.Collect(doc, newsdoc.WithBlockSource())
+
+	test.EqualDiffWithOptionsf(t, want, got, nil,
+		"set results must match individual Collect() calls")
+}
+
+func TestExtractorSetInvalidExpression(t *testing.T) {
+	_, err := newsdoc.ExtractorSetFromStrings(map[string]string{
+		"ok":  "@{title}",
+		"bad": ".widgets(type='a').data{date}",
+	})
+	if err == nil {
+		t.Fatal("expected an error for an invalid expression")
+	}
+}
+
+func TestExtractorSetNames(t *testing.T) {
+	set, err := newsdoc.ExtractorSetFromStrings(map[string]string{
+		"b": "@{title}",
+		"a": ".meta@{type}",
+	})
+	test.Mustf(t, err, "compile extractor set")
+
+	names := set.Names()
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("expected sorted names [a b], got %v", names)
+	}
+}
+
+// benchmarkExpressions generates a large set of expressions sharing selector
+// prefixes, similar to what an indexer would use.
+func benchmarkExpressions() map[string]string {
+	selectors := []string{
+		".meta(type='core/planning-item')",
+		".meta(type='core/assignment')",
+		".meta(type='core/assignment').links(rel='deliverable')",
+		".meta(type='core/assignment').meta(type='core/assignment-type')",
+		".meta(type='core/description' role='internal')",
+		".links(rel='section')",
+		".links(rel='event' type='core/event')",
+		".meta(type='core/newsvalue')",
+		".meta(type='tt/slugline')",
+		".links",
+	}
+
+	expressions := make(map[string]string)
+
+	for i := range 150 {
+		sel := selectors[i%len(selectors)]
+
+		switch i % 3 {
+		case 0:
+			expressions[fmt.Sprintf("e%d", i)] = fmt.Sprintf(
+				"%s.data{start_date? k%d?}", sel, i)
+		case 1:
+			expressions[fmt.Sprintf("e%d", i)] = fmt.Sprintf(
+				"%s@{uuid? value? title?}", sel)
+		case 2:
+			expressions[fmt.Sprintf("e%d", i)] = fmt.Sprintf(
+				"%s@{type}.data{public? k%d?}", sel, i)
+		}
+	}
+
+	return expressions
+}
+
+func loadBenchmarkDocument(b *testing.B) newsdoc.Document {
+	b.Helper()
+
+	var doc newsdoc.Document
+
+	err := test.UnmarshalFile(
+		filepath.Join("testdata", "TestValueExtractor", "planning.json"),
+		&doc)
+	test.Mustf(b, err, "unmarshal document")
+
+	return doc
+}
+
+func BenchmarkExtractorSetCollect(b *testing.B) {
+	doc := loadBenchmarkDocument(b)
+
+	set, err := newsdoc.ExtractorSetFromStrings(benchmarkExpressions())
+	test.Mustf(b, err, "compile extractor set")
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_ = set.Collect(doc)
+	}
+}
+
+func BenchmarkIndividualCollect(b *testing.B) {
+	doc := loadBenchmarkDocument(b)
+
+	var extractors []*newsdoc.ValueExtractor
+
+	for _, exp := range benchmarkExpressions() {
+		ve, err := newsdoc.ValueExtractorFromString(exp)
+		test.Mustf(b, err, "parse expression %q", exp)
+
+		extractors = append(extractors, ve)
+	}
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		res := make(map[int][]newsdoc.ExtractedItems, len(extractors))
+
+		for i, ve := range extractors {
+			res[i] = ve.Collect(doc)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ttab/newsdoc"
+)
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Language string       `xml:"xml:lang,attr,omitempty"`
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	Updated  string       `xml:"updated"`
+	Authors  []atomPerson `xml:"author"`
+	Links    []atomLink   `xml:"link"`
+	Entries  []atomEntry  `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Authors    []atomPerson   `xml:"author"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+	URI   string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term   string `xml:"term,attr"`
+	Scheme string `xml:"scheme,attr,omitempty"`
+	Label  string `xml:"label,attr,omitempty"`
+}
+
+// Atom writes the documents as an Atom feed. Entries that haven't been
+// updated use the publication time, or the feed update time, as the update
+// time. Images are written as enclosure links. The feed and all entries must
+// have IDs, and the feed must have an update time.
+func (w *Writer) Atom(out io.Writer, feed Feed, docs []newsdoc.Document) error {
+	entries := w.Entries(docs)
+
+	updated := feedUpdated(feed, entries)
+	if updated.IsZero() {
+		return errors.New("the feed has no update time")
+	}
+
+	f := atomFeed{
+		Language: feed.Language,
+		ID:       feed.ID,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  updated.Format(time.RFC3339),
+	}
+
+	for _, a := range feed.Authors {
+		f.Authors = append(f.Authors, atomPerson(a))
+	}
+
+	if f.ID == "" {
+		f.ID = feed.Link
+	}
+
+	if f.ID == "" {
+		return errors.New("the feed has no ID or link")
+	}
+
+	if feed.Link != "" {
+		f.Links = append(f.Links, atomLink{Rel: "alternate", Href: feed.Link})
+	}
+
+	if feed.SelfLink != "" {
+		f.Links = append(f.Links, atomLink{Rel: "self", Href: feed.SelfLink})
+	}
+
+	for i, e := range entries {
+		if e.ID == "" {
+			return fmt.Errorf("document %d has no URI or UUID to use as entry ID", i)
+		}
+
+		entryUpdated := e.Updated
+		if entryUpdated.IsZero() {
+			entryUpdated = e.Published
+		}
+
+		if entryUpdated.IsZero() {
+			entryUpdated = updated
+		}
+
+		ae := atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Updated: entryUpdated.Format(time.RFC3339),
+			Summary: e.Summary,
+		}
+
+		if !e.Published.IsZero() {
+			ae.Published = e.Published.Format(time.RFC3339)
+		}
+
+		for _, a := range e.Authors {
+			ae.Authors = append(ae.Authors, atomPerson(a))
+		}
+
+		if e.Link != "" {
+			ae.Links = append(ae.Links, atomLink{Rel: "alternate", Href: e.Link})
+		}
+
+		for _, img := range e.Images {
+			l := atomLink{Rel: "enclosure", Href: img.URL, Type: img.Type}
+
+			if img.Length > 0 {
+				l.Length = strconv.FormatInt(img.Length, 10)
+			}
+
+			ae.Links = append(ae.Links, l)
+		}
+
+		for _, c := range e.Categories {
+			ae.Categories = append(ae.Categories, atomCategory(c))
+		}
+
+		f.Entries = append(f.Entries, ae)
+	}
+
+	return writeXML(out, f)
+}
+
+// feedUpdated returns the update time of the feed, or the most recent entry
+// update or publication time.
+func feedUpdated(feed Feed, entries []Entry) time.Time {
+	if !feed.Updated.IsZero() {
+		return feed.Updated
+	}
+
+	var latest time.Time
+
+	for _, e := range entries {
+		for _, t := range []time.Time{e.Published, e.Updated} {
+			if t.After(latest) {
+				latest = t
+			}
+		}
+	}
+
+	return latest
+}
+
+func writeXML(out io.Writer, v any) error {
+	_, err := io.WriteString(out, xml.Header)
+	if err != nil {
+		return fmt.Errorf("write XML header: %w", err)
+	}
+
+	enc := xml.NewEncoder(out)
+
+	enc.Indent("", "  ")
+
+	err = enc.Encode(v)
+	if err != nil {
+		return fmt.Errorf("encode feed: %w", err)
+	}
+
+	_, err = io.WriteString(out, "\n")
+	if err != nil {
+		return fmt.Errorf("write feed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,379 @@
+// Package feed writes Atom and RSS 2.0 feeds from NewsDoc documents.
+//
+// The entry values, like the title, summary, authors and enclosure images,
+// are read from the documents using value extractor expressions, see
+// newsdoc.ValueExtractor.
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ttab/newsdoc"
+)
+
+// Config lists the value extractor expressions used to read entry values
+// from documents.
+//
+// The expressions of single value fields, like the title, are tried in order
+// and the first value that is found is used. The values of the expressions
+// for authors, categories and images are combined. Multi-value fields read
+// their values by role, f.ex. "@{name=title}", or by name, f.ex. "@{url}".
+type Config struct {
+	// Title is the entry title.
+	Title []string `json:"title"`
+	// Summary is the entry summary, markup and line breaks are stripped
+	// from the text.
+	Summary []string `json:"summary"`
+	// Link is the URL of the entry.
+	Link []string `json:"link"`
+	// Published is the publication time of the entry as a RFC 3339
+	// timestamp, or a date.
+	Published []string `json:"published"`
+	// Updated is the time the entry was last updated, in the same format
+	// as the publication time.
+	Updated []string `json:"updated"`
+	// Authors have a name and optionally an email and an uri.
+	Authors []string `json:"authors"`
+	// Categories have a term, and optionally a scheme and a label.
+	Categories []string `json:"categories"`
+	// Images are enclosures with an url, and optionally a type and a
+	// length in bytes.
+	Images []string `json:"images"`
+}
+
+// DefaultConfig returns a configuration that reads the document title and
+// URL, the summary from core/teaser, public core/description and preamble
+// text blocks, the publication and update times from the IPTC firstcreated and
+// versioncreated data keys of any meta block, authors from author links,
+// categories from subject, section and category links, and images from image
+// links and core/image content blocks.
+//
+// The data keys are matched with the spelling used by both ninjs and
+// NewsML-G2, f.ex. "firstcreated" and "firstCreated", regardless of the type
+// of the meta block that they are kept in.
+func DefaultConfig() Config {
+	return Config{
+		Title: []string{"@{title}"},
+		Summary: []string{
+			".meta(type='core/teaser').data{text}",
+			".meta(type='core/description' role='public').data{text}",
+			".content(type='core/text' role='preamble').data{text}",
+		},
+		Link: []string{"@{url}"},
+		Published: []string{
+			".meta.data{firstcreated}",
+			".meta.data{firstCreated}",
+		},
+		Updated: []string{
+			".meta.data{versioncreated}",
+			".meta.data{versionCreated}",
+		},
+		Authors: []string{
+			".links(rel='author')@{name=title}.data{email?}",
+		},
+		Categories: []string{
+			".links(rel='subject' or rel='section' or rel='category')@{term=title scheme=uri?}",
+		},
+		Images: []string{
+			".links(rel='image')@{url type=contenttype?}.data{length?}",
+			".content(type='core/image')@{url type=contenttype?}",
+		},
+	}
+}
+
+// Feed describes the feed itself.
+type Feed struct {
+	// ID is the Atom feed ID, defaults to the link.
+	ID string
+	// Title is the title of the feed.
+	Title string
+	// Description is the Atom subtitle, or the RSS channel description.
+	Description string
+	// Link is the URL of the web page that the feed is published for.
+	Link string
+	// SelfLink is the URL of the feed.
+	SelfLink string
+	// Language is the language of the feed as an IETF language tag.
+	Language string
+	// Authors are the Atom feed authors, they are required by Atom
+	// unless all entries have authors.
+	Authors []Person
+	// Updated is the time that the feed was last updated, defaults to
+	// the most recent entry update.
+	Updated time.Time
+}
+
+// Entry is a feed entry read from a document.
+type Entry struct {
+	ID         string      `json:"id"`
+	Title      string      `json:"title"`
+	Summary    string      `json:"summary,omitempty"`
+	Link       string      `json:"link,omitempty"`
+	Published  time.Time   `json:"published,omitzero"`
+	Updated    time.Time   `json:"updated,omitzero"`
+	Authors    []Person    `json:"authors,omitempty"`
+	Categories []Category  `json:"categories,omitempty"`
+	Images     []Enclosure `json:"images,omitempty"`
+}
+
+// Person is an entry author.
+type Person struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	URI   string `json:"uri,omitempty"`
+}
+
+// Category is an entry category.
+type Category struct {
+	Term   string `json:"term"`
+	Scheme string `json:"scheme,omitempty"`
+	Label  string `json:"label,omitempty"`
+}
+
+// Enclosure is an image attached to an entry.
+type Enclosure struct {
+	URL    string `json:"url"`
+	Type   string `json:"type"`
+	Length int64  `json:"length,omitempty"`
+}
+
+// Writer writes feeds using compiled configuration expressions.
+type Writer struct {
+	title      []*newsdoc.ValueExtractor
+	summary    []*newsdoc.ValueExtractor
+	link       []*newsdoc.ValueExtractor
+	published  []*newsdoc.ValueExtractor
+	updated    []*newsdoc.ValueExtractor
+	authors    []*newsdoc.ValueExtractor
+	categories []*newsdoc.ValueExtractor
+	images     []*newsdoc.ValueExtractor
+}
+
+// NewWriter compiles the expressions in the configuration.
+func NewWriter(cfg Config) (*Writer, error) {
+	var (
+		w    Writer
+		errs []error
+	)
+
+	fields := []struct {
+		Name        string
+		Expressions []string
+		Target      *[]*newsdoc.ValueExtractor
+	}{
+		{"title", cfg.Title, &w.title},
+		{"summary", cfg.Summary, &w.summary},
+		{"link", cfg.Link, &w.link},
+		{"published", cfg.Published, &w.published},
+		{"updated", cfg.Updated, &w.updated},
+		{"authors", cfg.Authors, &w.authors},
+		{"categories", cfg.Categories, &w.categories},
+		{"images", cfg.Images, &w.images},
+	}
+
+	for _, f := range fields {
+		for i, expr := range f.Expressions {
+			ve, err := newsdoc.ValueExtractorFromString(expr)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s[%d]: %w", f.Name, i, err))
+
+				continue
+			}
+
+			if ve.ValueKind == newsdoc.ValueKindBlock {
+				errs = append(errs, fmt.Errorf(
+					"%s[%d]: expression must extract values", f.Name, i))
+
+				continue
+			}
+
+			*f.Target = append(*f.Target, ve)
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed configuration: %w", err)
+	}
+
+	return &w, nil
+}
+
+// Entry reads a feed entry from a document. The entry ID is the document URI,
+// or a "urn:uuid:" URI based on the document UUID. The title falls back to the
+// document title, and then to the entry ID, if the title expressions don't
+// find a value.
+func (w *Writer) Entry(doc newsdoc.Document) Entry {
+	e := Entry{
+		ID:        doc.URI,
+		Title:     first(doc, w.title, "title"),
+		Summary:   summaryText(first(doc, w.summary, "summary")),
+		Link:      first(doc, w.link, "link"),
+		Published: firstTime(doc, w.published, "published"),
+		Updated:   firstTime(doc, w.updated, "updated"),
+	}
+
+	if e.ID == "" && doc.UUID != "" {
+		e.ID = "urn:uuid:" + doc.UUID
+	}
+
+	if e.Title == "" {
+		e.Title = doc.Title
+	}
+
+	if e.Title == "" {
+		e.Title = e.ID
+	}
+
+	for _, row := range rows(doc, w.authors) {
+		p := Person{
+			Name:  rowValue(row, "name"),
+			Email: rowValue(row, "email"),
+			URI:   rowValue(row, "uri"),
+		}
+
+		if p.Name != "" && !slices.Contains(e.Authors, p) {
+			e.Authors = append(e.Authors, p)
+		}
+	}
+
+	for _, row := range rows(doc, w.categories) {
+		c := Category{
+			Term:   rowValue(row, "term"),
+			Scheme: rowValue(row, "scheme"),
+			Label:  rowValue(row, "label"),
+		}
+
+		if c.Term != "" && !slices.Contains(e.Categories, c) {
+			e.Categories = append(e.Categories, c)
+		}
+	}
+
+	for _, row := range rows(doc, w.images) {
+		img := Enclosure{
+			URL:  rowValue(row, "url"),
+			Type: rowValue(row, "type"),
+		}
+
+		if img.URL == "" || slices.ContainsFunc(e.Images, func(o Enclosure) bool {
+			return o.URL == img.URL
+		}) {
+			continue
+		}
+
+		if img.Type == "" {
+			img.Type = guessType(img.URL)
+		}
+
+		img.Length, _ = strconv.ParseInt(rowValue(row, "length"), 10, 64)
+
+		e.Images = append(e.Images, img)
+	}
+
+	return e
+}
+
+// Entries reads the feed entries from documents.
+func (w *Writer) Entries(docs []newsdoc.Document) []Entry {
+	entries := make([]Entry, len(docs))
+
+	for i := range docs {
+		entries[i] = w.Entry(docs[i])
+	}
+
+	return entries
+}
+
+func summaryText(text string) string {
+	return strings.Join(strings.Fields(newsdoc.StripMarkup(text)), " ")
+}
+
+// rows collects the rows of all the extractors.
+func rows(doc newsdoc.Document, extractors []*newsdoc.ValueExtractor) []newsdoc.ExtractedItems {
+	var res []newsdoc.ExtractedItems
+
+	for _, ve := range extractors {
+		res = append(res, ve.Collect(doc)...)
+	}
+
+	return res
+}
+
+// first returns the first value found by the extractors.
+func first(doc newsdoc.Document, extractors []*newsdoc.ValueExtractor, field string) string {
+	for _, ve := range extractors {
+		for _, row := range ve.Collect(doc) {
+			v := rowValue(row, field)
+			if v == "" && len(ve.Values) > 0 {
+				v = row[ve.Values[0].Name].Value
+			}
+
+			if v != "" {
+				return v
+			}
+		}
+	}
+
+	return ""
+}
+
+// firstTime returns the first valid time found by the extractors.
+func firstTime(doc newsdoc.Document, extractors []*newsdoc.ValueExtractor, field string) time.Time {
+	for _, ve := range extractors {
+		t, ok := parseTime(first(doc, []*newsdoc.ValueExtractor{ve}, field))
+		if ok {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// rowValue returns the value with the role, or the value with the name if
+// there is no value with the role.
+func rowValue(row newsdoc.ExtractedItems, name string) string {
+	for _, v := range row {
+		if v.Role == name {
+			return v.Value
+		}
+	}
+
+	v, ok := row[name]
+	if !ok || v.Role != "" {
+		return ""
+	}
+
+	return v.Value
+}
+
+// guessType guesses the media type of an image from the URL path.
+func guessType(u string) string {
+	u, _, _ = strings.Cut(u, "?")
+
+	t := mime.TypeByExtension(path.Ext(u))
+	if t == "" {
+		return "application/octet-stream"
+	}
+
+	t, _, _ = strings.Cut(t, ";")
+
+	return t
+}
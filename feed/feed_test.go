@@ -0,0 +1,220 @@
+package feed_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/feed"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func loadDocuments(t *testing.T) []newsdoc.Document {
+	t.Helper()
+
+	var docs []newsdoc.Document
+
+	err := test.UnmarshalFile(filepath.Join("testdata", "documents.json"), &docs)
+	test.Mustf(t, err, "unmarshal documents")
+
+	return docs
+}
+
+func newWriter(t *testing.T, cfg feed.Config) *feed.Writer {
+	t.Helper()
+
+	w, err := feed.NewWriter(cfg)
+	test.Mustf(t, err, "create feed writer")
+
+	return w
+}
+
+var sportFeed = feed.Feed{
+	Title:       "Sport",
+	Description: "The latest sport news.",
+	Link:        "https://example.com/sport",
+	SelfLink:    "https://example.com/sport/feed",
+	Language:    "en",
+	Authors:     []feed.Person{{Name: "The sports desk"}},
+}
+
+func TestEntries(t *testing.T) {
+	w := newWriter(t, feed.DefaultConfig())
+
+	test.AgainstGolden(t, test.Regenerate(), w.Entries(loadDocuments(t)),
+		filepath.Join("testdata", t.Name(), "entries.json"))
+}
+
+func TestAtom(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := newWriter(t, feed.DefaultConfig()).Atom(&buf, sportFeed, loadDocuments(t))
+	test.Mustf(t, err, "write Atom feed")
+
+	test.AgainstGoldenText(t, test.Regenerate(), buf.String(),
+		filepath.Join("testdata", t.Name(), "feed.xml"))
+}
+
+func TestRSS(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := newWriter(t, feed.DefaultConfig()).RSS(&buf, sportFeed, loadDocuments(t))
+	test.Mustf(t, err, "write RSS feed")
+
+	test.AgainstGoldenText(t, test.Regenerate(), buf.String(),
+		filepath.Join("testdata", t.Name(), "feed.xml"))
+}
+
+func TestCustomConfig(t *testing.T) {
+	cfg := feed.Config{
+		Title:      []string{".meta(type='core/teaser')@{title}", "@{title}"},
+		Published:  []string{".meta(type='example/dates').data{published}"},
+		Categories: []string{".links(rel='subject')@{term=value label=title}"},
+		Images:     []string{".links(rel='image')@{url}"},
+	}
+
+	doc := newsdoc.Document{
+		UUID:  "5b2d7e1c-0c8b-4a3e-9d55-7c1e0a6f4b21",
+		Title: "The document title",
+		Meta: []newsdoc.Block{
+			{Type: "core/teaser", Title: "The teaser title"},
+			{Type: "example/dates", Data: newsdoc.DataMap{
+				"published": "2024-05-04T12:00:00+02:00",
+			}},
+		},
+		Links: []newsdoc.Block{
+			{Rel: "subject", Value: "football", Title: "Football"},
+			{Rel: "subject", Value: "football", Title: "Football"},
+			{Rel: "image", URL: "https://example.com/a.webp?w=800"},
+		},
+	}
+
+	got := newWriter(t, cfg).Entry(doc)
+
+	want := feed.Entry{
+		ID:        "urn:uuid:5b2d7e1c-0c8b-4a3e-9d55-7c1e0a6f4b21",
+		Title:     "The teaser title",
+		Published: time.Date(2024, 5, 4, 10, 0, 0, 0, time.UTC),
+		Categories: []feed.Category{
+			{Term: "football", Label: "Football"},
+		},
+		Images: []feed.Enclosure{
+			{URL: "https://example.com/a.webp?w=800", Type: "image/webp"},
+		},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, got, nil, "must read the custom values")
+}
+
+func TestInvalidConfig(t *testing.T) {
+	cfg := feed.DefaultConfig()
+
+	cfg.Summary = append(cfg.Summary, ".meta(type='core/teaser'")
+	cfg.Images = []string{"images=.links(rel='image')"}
+
+	_, err := feed.NewWriter(cfg)
+	if err == nil {
+		t.Fatal("expected an invalid configuration to fail")
+	}
+}
+
+func TestAtomInvalid(t *testing.T) {
+	now := time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		Feed feed.Feed
+		Doc  newsdoc.Document
+	}{
+		"no update time": {
+			Feed: sportFeed,
+			Doc:  newsdoc.Document{URI: "https://example.com/a", Title: "A"},
+		},
+		"no feed ID": {
+			Feed: feed.Feed{Title: "x", Updated: now},
+			Doc:  newsdoc.Document{URI: "https://example.com/a", Title: "A"},
+		},
+		"no entry ID": {
+			Feed: feed.Feed{ID: "urn:example:feed", Title: "x", Updated: now},
+			Doc:  newsdoc.Document{Title: "No identity"},
+		},
+	}
+
+	w := newWriter(t, feed.DefaultConfig())
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := w.Atom(&buf, c.Feed, []newsdoc.Document{c.Doc})
+			if err == nil {
+				t.Fatalf("expected an error, got:\n%s", buf.String())
+			}
+
+			t.Logf("got expected error: %v", err)
+		})
+	}
+}
+
+func TestDefaultConfigTimes(t *testing.T) {
+	doc := newsdoc.Document{
+		URI:   "https://example.com/a",
+		Title: "A",
+		Meta: []newsdoc.Block{
+			{Type: "example/dates", Data: newsdoc.DataMap{
+				"firstCreated":   "2024-05-04",
+				"versionCreated": "2024-05-05T08:00:00Z",
+			}},
+		},
+	}
+
+	got := newWriter(t, feed.DefaultConfig()).Entry(doc)
+
+	test.EqualDiffWithOptionsf(t, time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC),
+		got.Published, nil, "must read the publication time from any meta block")
+	test.EqualDiffWithOptionsf(t, time.Date(2024, 5, 5, 8, 0, 0, 0, time.UTC),
+		got.Updated, nil, "must read the update time from any meta block")
+}
+
+func TestEntryTitleFallback(t *testing.T) {
+	w := newWriter(t, feed.Config{
+		Title: []string{".meta(type='core/teaser')@{title}"},
+	})
+
+	cases := map[string]struct {
+		Doc  newsdoc.Document
+		Want string
+	}{
+		"document title": {
+			Doc:  newsdoc.Document{URI: "https://example.com/a", Title: "A"},
+			Want: "A",
+		},
+		"document URI": {
+			Doc:  newsdoc.Document{URI: "https://example.com/a"},
+			Want: "https://example.com/a",
+		},
+		"document UUID": {
+			Doc:  newsdoc.Document{UUID: "5b2d7e1c-0c8b-4a3e-9d55-7c1e0a6f4b21"},
+			Want: "urn:uuid:5b2d7e1c-0c8b-4a3e-9d55-7c1e0a6f4b21",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			test.EqualDiffWithOptionsf(t, c.Want, w.Entry(c.Doc).Title, nil,
+				"unexpected entry title")
+		})
+	}
+}
+
+func TestRSSInvalid(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := newWriter(t, feed.DefaultConfig()).RSS(&buf, sportFeed, []newsdoc.Document{
+		{Type: "core/article"},
+	})
+	if err == nil {
+		t.Fatalf("expected an error, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ttab/newsdoc"
+)
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr,omitempty"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	SelfLink      *rssSelf  `xml:"atom:link,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssSelf struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title,omitempty"`
+	Link        string        `xml:"link,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	Author      string        `xml:"author,omitempty"`
+	Categories  []rssCategory `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+	GUID        *rssGUID      `xml:"guid,omitempty"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+}
+
+type rssCategory struct {
+	Domain string `xml:"domain,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS writes the documents as a RSS 2.0 feed. RSS only allows one author and
+// one enclosure per item, so only the first author and the first image are
+// written. The author is written as "email (name)", or just the name if the
+// author doesn't have an email. Every item must have a title or a
+// description, see Writer.Entry() for how the title is read.
+func (w *Writer) RSS(out io.Writer, feed Feed, docs []newsdoc.Document) error {
+	entries := w.Entries(docs)
+
+	f := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       feed.Title,
+			Link:        feed.Link,
+			Description: feed.Description,
+			Language:    feed.Language,
+		},
+	}
+
+	if updated := feedUpdated(feed, entries); !updated.IsZero() {
+		f.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	if feed.SelfLink != "" {
+		f.AtomNS = "http://www.w3.org/2005/Atom"
+		f.Channel.SelfLink = &rssSelf{
+			Rel:  "self",
+			Href: feed.SelfLink,
+			Type: "application/rss+xml",
+		}
+	}
+
+	for i, e := range entries {
+		if e.Title == "" && e.Summary == "" {
+			return fmt.Errorf("document %d has no title or description", i)
+		}
+
+		item := rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Summary,
+		}
+
+		if e.ID != "" {
+			item.GUID = &rssGUID{
+				IsPermaLink: strconv.FormatBool(e.ID == e.Link),
+				Value:       e.ID,
+			}
+		}
+
+		if !e.Published.IsZero() {
+			item.PubDate = e.Published.Format(time.RFC1123Z)
+		}
+
+		if len(e.Authors) > 0 {
+			a := e.Authors[0]
+
+			item.Author = a.Name
+			if a.Email != "" {
+				item.Author = a.Email + " (" + a.Name + ")"
+			}
+		}
+
+		for _, c := range e.Categories {
+			item.Categories = append(item.Categories, rssCategory{
+				Domain: c.Scheme,
+				Value:  c.Term,
+			})
+		}
+
+		if len(e.Images) > 0 {
+			img := e.Images[0]
+
+			// The length is required, zero is used when it's unknown.
+			item.Enclosure = &rssEnclosure{
+				URL:    img.URL,
+				Length: strconv.FormatInt(img.Length, 10),
+				Type:   img.Type,
+			}
+		}
+
+		f.Channel.Items = append(f.Channel.Items, item)
+	}
+
+	return writeXML(out, f)
+}
@@ -5,9 +5,13 @@ go 1.25.7
 require (
 	github.com/fatih/structtag v1.2.0
 	github.com/google/go-cmp v0.7.0
+	github.com/google/uuid v1.6.0
 	github.com/invopop/jsonschema v0.14.0
 	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	github.com/theory/jsonpath v0.10.2
 	github.com/urfave/cli/v2 v2.27.7
+	github.com/yuin/goldmark v1.8.6
+	golang.org/x/net v0.50.0
 )
 
 require (
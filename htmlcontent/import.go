@@ -0,0 +1,576 @@
+package htmlcontent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ttab/newsdoc"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// BlockType is the type and role of a block.
+type BlockType struct {
+	Type string `json:"type"`
+	Role string `json:"role,omitempty"`
+}
+
+// Block returns a new block with the type and role.
+func (bt BlockType) Block() newsdoc.Block {
+	return newsdoc.Block{
+		Type: bt.Type,
+		Role: bt.Role,
+	}
+}
+
+// Matches checks if the block has the type and role. An empty block type
+// doesn't match any block.
+func (bt BlockType) Matches(b newsdoc.Block) bool {
+	return bt.Type != "" && b.Type == bt.Type && b.Role == bt.Role
+}
+
+// ImportConfig maps HTML elements to content block types.
+type ImportConfig struct {
+	// Text elements become blocks with their inline content in
+	// data.text. Block level elements inside text elements, like nested
+	// lists in list items, become the content of the block. Inline content
+	// outside of text elements is wrapped in a block of the type used for
+	// p elements.
+	Text map[string]BlockType `json:"text"`
+	// Containers become blocks with the blocks of their child elements as
+	// content.
+	Containers map[string]BlockType `json:"containers"`
+	// Transparent elements are replaced by the blocks of their child
+	// elements.
+	Transparent []string `json:"transparent"`
+	// Roles are class names that become the role of blocks from text
+	// elements that don't have a configured role, f.ex. "preamble" for
+	// <p class="preamble">.
+	Roles []string `json:"roles,omitempty"`
+	// Image is the block type for img and figure elements. The image URL
+	// is stored as the block URL, the alt text in data.alt, and the
+	// caption and credit of figures in data.text and data.credit.
+	Image BlockType `json:"image"`
+}
+
+// DefaultImportConfig returns an import configuration that matches the
+// default render functions: core/text blocks with roles for headings, list
+// items and preformatted text, core/unordered-list and core/ordered-list for
+// lists, core/blockquote for block quotes, and core/image for images.
+func DefaultImportConfig() ImportConfig {
+	return ImportConfig{
+		Text: map[string]BlockType{
+			"p":   {Type: "core/text"},
+			"h1":  {Type: "core/text", Role: "heading-1"},
+			"h2":  {Type: "core/text", Role: "heading-2"},
+			"h3":  {Type: "core/text", Role: "heading-3"},
+			"h4":  {Type: "core/text", Role: "heading-4"},
+			"h5":  {Type: "core/text", Role: "heading-5"},
+			"h6":  {Type: "core/text", Role: "heading-6"},
+			"li":  {Type: "core/text", Role: "list-item"},
+			"pre": {Type: "core/text", Role: "preformatted"},
+		},
+		Containers: map[string]BlockType{
+			"ul":         {Type: "core/unordered-list"},
+			"ol":         {Type: "core/ordered-list"},
+			"blockquote": {Type: "core/blockquote"},
+		},
+		Transparent: []string{
+			"article", "div", "header", "footer", "main", "section",
+		},
+		Image: BlockType{Type: "core/image"},
+	}
+}
+
+// Validate checks that the image block type is set, and that no element is
+// configured more than once.
+func (c ImportConfig) Validate() error {
+	if c.Image.Type == "" {
+		return errors.New("no block type for images")
+	}
+
+	seen := make(map[string]bool)
+
+	check := func(name string) error {
+		if name == "img" || name == "figure" {
+			return fmt.Errorf("%q elements are always imported as images", name)
+		}
+
+		if seen[name] {
+			return fmt.Errorf("%q is configured more than once", name)
+		}
+
+		seen[name] = true
+
+		return nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Text)) {
+		if c.Text[name].Type == "" {
+			return fmt.Errorf("no block type for %q", name)
+		}
+
+		err := check(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Containers)) {
+		if c.Containers[name].Type == "" {
+			return fmt.Errorf("no block type for %q", name)
+		}
+
+		err := check(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range c.Transparent {
+		err := check(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DropReason describes why an element was dropped during import.
+type DropReason string
+
+const (
+	// DropUnsupported is used for elements that were removed together
+	// with their content.
+	DropUnsupported DropReason = "unsupported"
+	// DropFormatting is used for inline elements that were removed, but
+	// whose text was kept.
+	DropFormatting DropReason = "formatting"
+	// DropInvalidImage is used for images without a source, or with a
+	// source that isn't a safe URL.
+	DropInvalidImage DropReason = "invalid-image"
+)
+
+// DroppedElement is an element that was left out of the imported content.
+type DroppedElement struct {
+	// Path is the path of element names from the body to the element,
+	// f.ex. "div/table".
+	Path    string     `json:"path"`
+	Element string     `json:"element"`
+	Reason  DropReason `json:"reason"`
+}
+
+// ImportReport describes the elements that were dropped during import.
+type ImportReport struct {
+	Dropped []DroppedElement `json:"dropped,omitempty"`
+}
+
+// phrasingElements are the elements that are treated as inline content, the
+// ones that aren't allowed inline formatting are removed but their text is
+// kept.
+var phrasingElements = map[atom.Atom]bool{
+	atom.Abbr:  true,
+	atom.Bdi:   true,
+	atom.Bdo:   true,
+	atom.Cite:  true,
+	atom.Data:  true,
+	atom.Del:   true,
+	atom.Dfn:   true,
+	atom.Font:  true,
+	atom.Ins:   true,
+	atom.Kbd:   true,
+	atom.Q:     true,
+	atom.Samp:  true,
+	atom.Small: true,
+	atom.Span:  true,
+	atom.Time:  true,
+	atom.Var:   true,
+	atom.Wbr:   true,
+}
+
+// Import converts HTML to content blocks. Both complete documents and
+// fragments are accepted, only the content of the body is imported.
+//
+// Block level elements are mapped to blocks using the configuration, and the
+// inline content of text elements is kept as inline HTML, see
+// SanitizeInline(). Images are imported as image blocks, images inside text
+// elements are placed after the text block. Elements that aren't configured
+// are dropped together with their content and listed in the report.
+func Import(r io.Reader, cfg ImportConfig) ([]newsdoc.Block, ImportReport, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, ImportReport{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	doc, err := xhtml.Parse(r)
+	if err != nil {
+		return nil, ImportReport{}, fmt.Errorf("parse HTML: %w", err)
+	}
+
+	body := findElement(doc, atom.Body)
+	if body == nil {
+		return nil, ImportReport{}, nil
+	}
+
+	imp := importer{cfg: cfg}
+
+	blocks := imp.blocks(children(body), "")
+
+	return blocks, imp.report, nil
+}
+
+var paragraphBreakExp = regexp.MustCompile(`\n[ \t]*\n`)
+
+// ImportText imports plain text as content blocks. Paragraphs are separated
+// by empty lines and become blocks of the type configured for p elements.
+func ImportText(text string, cfg ImportConfig) ([]newsdoc.Block, ImportReport, error) {
+	var sb strings.Builder
+
+	for _, p := range paragraphBreakExp.Split(text, -1) {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		sb.WriteString("<p>")
+		sb.WriteString(html.EscapeString(p))
+		sb.WriteString("</p>\n")
+	}
+
+	return Import(strings.NewReader(sb.String()), cfg)
+}
+
+type importer struct {
+	cfg    ImportConfig
+	report ImportReport
+}
+
+func (imp *importer) drop(path string, n *xhtml.Node, reason DropReason) {
+	imp.report.Dropped = append(imp.report.Dropped, DroppedElement{
+		Path:    path,
+		Element: n.Data,
+		Reason:  reason,
+	})
+}
+
+func (imp *importer) isInline(n *xhtml.Node) bool {
+	switch n.Type {
+	case xhtml.TextNode:
+		return true
+	case xhtml.ElementNode:
+		return inlineTags[n.DataAtom] || phrasingElements[n.DataAtom]
+	case xhtml.ErrorNode, xhtml.DocumentNode, xhtml.CommentNode,
+		xhtml.DoctypeNode, xhtml.RawNode:
+	}
+
+	return false
+}
+
+func (imp *importer) blocks(nodes []*xhtml.Node, path string) []newsdoc.Block {
+	var (
+		res []newsdoc.Block
+		run []*xhtml.Node
+	)
+
+	// Inline content outside of text elements is collected and wrapped
+	// in a paragraph.
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+
+		nodes := run
+
+		run = nil
+
+		bt, ok := imp.cfg.Text["p"]
+		if !ok {
+			for _, n := range nodes {
+				if n.Type == xhtml.ElementNode {
+					imp.drop(joinPath(path, n.Data), n, DropUnsupported)
+				}
+			}
+
+			return
+		}
+
+		text := imp.inline(nodes, path, false)
+		if text == "" {
+			return
+		}
+
+		b := bt.Block()
+
+		b.Data = newsdoc.DataMap{"text": text}
+
+		res = append(res, b)
+	}
+
+	for _, n := range nodes {
+		if imp.isInline(n) {
+			run = append(run, n)
+
+			continue
+		}
+
+		if n.Type != xhtml.ElementNode {
+			continue
+		}
+
+		flush()
+
+		res = append(res, imp.element(n, joinPath(path, n.Data))...)
+	}
+
+	flush()
+
+	return res
+}
+
+func (imp *importer) element(n *xhtml.Node, path string) []newsdoc.Block {
+	if n.DataAtom == atom.Img || n.DataAtom == atom.Figure {
+		return imp.image(n, path)
+	}
+
+	if bt, ok := imp.cfg.Text[n.Data]; ok {
+		return imp.text(n, bt, path)
+	}
+
+	if bt, ok := imp.cfg.Containers[n.Data]; ok {
+		b := bt.Block()
+
+		b.Content = imp.blocks(children(n), path)
+
+		start := attr(n, "start")
+		if n.DataAtom == atom.Ol && start != "" && start != "1" {
+			b.Data = newsdoc.DataMap{"start": start}
+		}
+
+		if len(b.Content) == 0 {
+			return nil
+		}
+
+		return []newsdoc.Block{b}
+	}
+
+	if slices.Contains(imp.cfg.Transparent, n.Data) {
+		return imp.blocks(children(n), path)
+	}
+
+	imp.drop(path, n, DropUnsupported)
+
+	return nil
+}
+
+// text converts a text element. Block level child elements become the
+// content of the block, except for images that are placed after it.
+func (imp *importer) text(n *xhtml.Node, bt BlockType, path string) []newsdoc.Block {
+	var inline, nested, images []*xhtml.Node
+
+	for _, c := range children(n) {
+		switch {
+		case imp.isInline(c):
+			inline = append(inline, c)
+		case c.DataAtom == atom.Img || c.DataAtom == atom.Figure:
+			images = append(images, c)
+		default:
+			nested = append(nested, c)
+		}
+	}
+
+	b := bt.Block()
+
+	if b.Role == "" {
+		for _, class := range strings.Fields(attr(n, "class")) {
+			if slices.Contains(imp.cfg.Roles, class) {
+				b.Role = class
+
+				break
+			}
+		}
+	}
+
+	text := imp.inline(inline, path, n.DataAtom == atom.Pre)
+	if text != "" {
+		b.Data = newsdoc.DataMap{"text": text}
+	}
+
+	b.Content = imp.blocks(nested, path)
+
+	var res []newsdoc.Block
+
+	if text != "" || len(b.Content) > 0 {
+		res = append(res, b)
+	}
+
+	return append(res, imp.blocks(images, path)...)
+}
+
+var (
+	spaceExp = regexp.MustCompile(`[ \t\n\r\f]+`)
+	breakExp = regexp.MustCompile(` ?<br> ?`)
+)
+
+// inline renders inline nodes as sanitised inline HTML. Whitespace is
+// collapsed unless it's preformatted, and text that only consists of
+// whitespace is returned as an empty string.
+func (imp *importer) inline(nodes []*xhtml.Node, path string, pre bool) string {
+	var buf bytes.Buffer
+
+	for _, n := range nodes {
+		imp.reportInline(n, path)
+
+		_ = xhtml.Render(&buf, n)
+	}
+
+	text := SanitizeInline(buf.String())
+
+	if !pre {
+		text = spaceExp.ReplaceAllString(text, " ")
+		text = breakExp.ReplaceAllString(text, "<br>")
+		text = strings.TrimSpace(text)
+	}
+
+	if newsdoc.StripMarkup(text) == "" {
+		return ""
+	}
+
+	return text
+}
+
+// reportInline reports the elements that SanitizeInline() will remove.
+func (imp *importer) reportInline(n *xhtml.Node, path string) {
+	if n.Type != xhtml.ElementNode {
+		return
+	}
+
+	p := joinPath(path, n.Data)
+
+	switch {
+	case inlineTags[n.DataAtom]:
+	case phrasingElements[n.DataAtom]:
+		imp.drop(p, n, DropFormatting)
+	default:
+		imp.drop(p, n, DropUnsupported)
+
+		return
+	}
+
+	for _, c := range children(n) {
+		imp.reportInline(c, p)
+	}
+}
+
+// image converts an img element, or a figure with an image and an optional
+// caption.
+func (imp *importer) image(n *xhtml.Node, path string) []newsdoc.Block {
+	img := n
+	if n.DataAtom == atom.Figure {
+		img = findElement(n, atom.Img)
+	}
+
+	if img == nil {
+		imp.drop(path, n, DropInvalidImage)
+
+		return nil
+	}
+
+	src := strings.TrimSpace(attr(img, "src"))
+	if src == "" || !SafeURL(src) {
+		imp.drop(path, n, DropInvalidImage)
+
+		return nil
+	}
+
+	b := imp.cfg.Image.Block()
+
+	b.URL = src
+	b.Data = newsdoc.DataMap{}
+
+	if alt := attr(img, "alt"); alt != "" {
+		b.Data["alt"] = alt
+	}
+
+	caption := findElement(n, atom.Figcaption)
+	if caption != nil {
+		imp.caption(&b, caption, joinPath(path, caption.Data))
+	}
+
+	return []newsdoc.Block{b}
+}
+
+// caption reads the caption of a figure into data.text. A span with the class
+// "credit" is read into data.credit.
+func (imp *importer) caption(b *newsdoc.Block, n *xhtml.Node, path string) {
+	var text []*xhtml.Node
+
+	for _, c := range children(n) {
+		if c.DataAtom == atom.Span && slices.Contains(
+			strings.Fields(attr(c, "class")), "credit") {
+			credit := newsdoc.StripMarkup(imp.inline(children(c), path, false))
+			if credit != "" {
+				b.Data["credit"] = credit
+			}
+
+			continue
+		}
+
+		text = append(text, c)
+	}
+
+	caption := imp.inline(text, path, false)
+	if caption != "" {
+		b.Data["text"] = caption
+	}
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "/" + name
+}
+
+func children(n *xhtml.Node) []*xhtml.Node {
+	var res []*xhtml.Node
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		res = append(res, c)
+	}
+
+	return res
+}
+
+func attr(n *xhtml.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func findElement(n *xhtml.Node, a atom.Atom) *xhtml.Node {
+	if n.Type == xhtml.ElementNode && n.DataAtom == a {
+		return n
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		found := findElement(c, a)
+		if found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package htmlcontent_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/htmlcontent"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func TestImport(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "legacy.html"))
+	test.Mustf(t, err, "open HTML file")
+
+	defer f.Close()
+
+	blocks, report, err := htmlcontent.Import(f, htmlcontent.DefaultImportConfig())
+	test.Mustf(t, err, "import HTML")
+
+	test.AgainstGolden(t, test.Regenerate(), blocks,
+		filepath.Join("testdata", t.Name(), "blocks.json"))
+
+	test.AgainstGolden(t, test.Regenerate(), report,
+		filepath.Join("testdata", t.Name(), "report.json"))
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	doc := loadArticle(t)
+
+	// Factboxes are rendered as asides, which aren't imported.
+	doc.Content = slices.DeleteFunc(doc.Content, func(b newsdoc.Block) bool {
+		return b.Type == "core/factbox"
+	})
+
+	cfg := htmlcontent.DefaultImportConfig()
+
+	cfg.Roles = []string{"preamble"}
+
+	var buf strings.Builder
+
+	err := htmlcontent.NewRenderer().RenderDocument(&buf, doc)
+	test.Mustf(t, err, "render document")
+
+	blocks, _, err := htmlcontent.Import(
+		strings.NewReader(buf.String()), cfg)
+	test.Mustf(t, err, "import rendered HTML")
+
+	var again strings.Builder
+
+	err = htmlcontent.NewRenderer().Render(&again, blocks)
+	test.Mustf(t, err, "render imported blocks")
+
+	test.EqualDiffWithOptionsf(t, buf.String(), again.String(), nil,
+		"imported blocks must render the same HTML")
+}
+
+func TestImportConfig(t *testing.T) {
+	cfg := htmlcontent.ImportConfig{
+		Text: map[string]htmlcontent.BlockType{
+			"h1": {Type: "core/heading", Role: "title"},
+		},
+		Containers: map[string]htmlcontent.BlockType{
+			"aside": {Type: "core/factbox"},
+		},
+		Image: htmlcontent.BlockType{Type: "tt/visual"},
+	}
+
+	blocks, report, err := htmlcontent.Import(strings.NewReader(
+		`<h1>Title</h1>Loose text<aside><h1>Facts</h1><img src="/a.jpg"></aside>`,
+	), cfg)
+	test.Mustf(t, err, "import HTML")
+
+	want := []newsdoc.Block{
+		{
+			Type: "core/heading",
+			Role: "title",
+			Data: newsdoc.DataMap{"text": "Title"},
+		},
+		{
+			Type: "core/factbox",
+			Content: []newsdoc.Block{
+				{
+					Type: "core/heading",
+					Role: "title",
+					Data: newsdoc.DataMap{"text": "Facts"},
+				},
+				{
+					Type: "tt/visual",
+					URL:  "/a.jpg",
+					Data: newsdoc.DataMap{},
+				},
+			},
+		},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, blocks, nil,
+		"must use the configured block types")
+
+	// Loose text is dropped when there is no mapping for p elements, but
+	// only elements are reported.
+	wantReport := htmlcontent.ImportReport{}
+
+	test.EqualDiffWithOptionsf(t, wantReport, report, nil,
+		"must not report dropped elements for plain text")
+
+	cfg.Transparent = []string{"aside"}
+
+	_, _, err = htmlcontent.Import(strings.NewReader(""), cfg)
+	if err == nil {
+		t.Fatal("expected an error for an element that is configured twice")
+	}
+
+	t.Logf("got expected error: %v", err)
+}
+
+func TestImportText(t *testing.T) {
+	text := "First paragraph <b>&amp;</b>\nwith two lines.\n \t\nSecond paragraph.\n\n\n"
+
+	blocks, report, err := htmlcontent.ImportText(text, htmlcontent.DefaultImportConfig())
+	test.Mustf(t, err, "import text")
+
+	want := []newsdoc.Block{
+		{Type: "core/text", Data: newsdoc.DataMap{
+			"text": "First paragraph &lt;b&gt;&amp;amp;&lt;/b&gt; with two lines.",
+		}},
+		{Type: "core/text", Data: newsdoc.DataMap{
+			"text": "Second paragraph.",
+		}},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, blocks, nil, "must import paragraphs")
+	test.EqualDiffWithOptionsf(t, htmlcontent.ImportReport{}, report, nil,
+		"must not drop anything")
+}
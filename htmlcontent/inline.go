@@ -0,0 +1,163 @@
+package htmlcontent
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"slices"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// inlineTags are the inline formatting elements that are kept in text.
+var inlineTags = map[atom.Atom]bool{
+	atom.A:      true,
+	atom.B:      true,
+	atom.Br:     true,
+	atom.Code:   true,
+	atom.Em:     true,
+	atom.I:      true,
+	atom.Mark:   true,
+	atom.S:      true,
+	atom.Strong: true,
+	atom.Sub:    true,
+	atom.Sup:    true,
+	atom.U:      true,
+}
+
+// droppedTags are elements that are removed together with their text.
+var droppedTags = map[atom.Atom]bool{
+	atom.Script: true,
+	atom.Style:  true,
+}
+
+// safeURLSchemes are the schemes allowed in link hrefs, relative URLs are
+// always allowed.
+var safeURLSchemes = []string{"http", "https", "mailto", "tel"}
+
+// SafeURL reports whether a URL is safe to use in a link or image, that is
+// if it's relative or uses one of the schemes http, https, mailto or tel.
+func SafeURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "" || slices.Contains(safeURLSchemes, strings.ToLower(u.Scheme))
+}
+
+// SanitizeInline cleans up inline HTML so that only the allowed inline
+// formatting elements remain: a, b, br, code, em, i, mark, s, strong, sub, sup
+// and u. Links keep their href if it's a safe URL, see SafeURL(). All other
+// elements are removed but their text is kept, except for scripts and styles
+// that are removed entirely. All text is escaped, and unclosed elements are
+// closed.
+func SanitizeInline(text string) string {
+	var (
+		buf     bytes.Buffer
+		stack   []atom.Atom
+		dropped int
+	)
+
+	z := xhtml.NewTokenizer(strings.NewReader(text))
+
+	for {
+		tt := z.Next()
+
+		switch tt {
+		case xhtml.ErrorToken:
+			for i := len(stack) - 1; i >= 0; i-- {
+				writeEndTag(&buf, stack[i])
+			}
+
+			return buf.String()
+		case xhtml.TextToken:
+			if dropped > 0 {
+				continue
+			}
+
+			buf.WriteString(html.EscapeString(string(z.Text())))
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			tok := z.Token()
+
+			if droppedTags[tok.DataAtom] && tt == xhtml.StartTagToken {
+				dropped++
+
+				continue
+			}
+
+			if !inlineTags[tok.DataAtom] {
+				continue
+			}
+
+			if tok.DataAtom == atom.Br {
+				buf.WriteString("<br>")
+
+				continue
+			}
+
+			// Self-closed formatting elements are empty.
+			if tt == xhtml.SelfClosingTagToken {
+				continue
+			}
+
+			writeStartTag(&buf, tok)
+
+			stack = append(stack, tok.DataAtom)
+		case xhtml.EndTagToken:
+			tok := z.Token()
+
+			if droppedTags[tok.DataAtom] && dropped > 0 {
+				dropped--
+
+				continue
+			}
+
+			// Close the innermost open element of the type.
+			idx := len(stack) - 1
+			for idx >= 0 && stack[idx] != tok.DataAtom {
+				idx--
+			}
+
+			if idx == -1 {
+				continue
+			}
+
+			// Close any elements that were left open inside the
+			// element.
+			for i := len(stack) - 1; i >= idx; i-- {
+				writeEndTag(&buf, stack[i])
+			}
+
+			stack = stack[:idx]
+		case xhtml.CommentToken, xhtml.DoctypeToken:
+		}
+	}
+}
+
+func writeStartTag(buf *bytes.Buffer, tok xhtml.Token) {
+	buf.WriteByte('<')
+	buf.WriteString(tok.DataAtom.String())
+
+	if tok.DataAtom == atom.A {
+		for _, attr := range tok.Attr {
+			if attr.Key != "href" || !SafeURL(attr.Val) {
+				continue
+			}
+
+			buf.WriteString(` href="`)
+			buf.WriteString(html.EscapeString(attr.Val))
+			buf.WriteByte('"')
+		}
+	}
+
+	buf.WriteByte('>')
+}
+
+func writeEndTag(buf *bytes.Buffer, a atom.Atom) {
+	buf.WriteString("</")
+	buf.WriteString(a.String())
+	buf.WriteByte('>')
+}
@@ -0,0 +1,315 @@
+// Package htmlcontent converts between NewsDoc content blocks and HTML.
+package htmlcontent
+
+import (
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ttab/newsdoc"
+)
+
+// RenderFunc renders a block using the writer.
+type RenderFunc func(w *Writer, b newsdoc.Block)
+
+// Renderer renders document content as HTML. Block types are mapped to render
+// functions, blocks of types without a render function are passed to the
+// fallback.
+type Renderer struct {
+	types    map[string]RenderFunc
+	fallback RenderFunc
+}
+
+// NewRenderer creates a renderer with the default render functions for
+// core/text, core/image, core/factbox, core/unordered-list, core/ordered-list
+// and core/blockquote blocks. The default fallback renders the nested content
+// of unknown blocks, but nothing of the blocks themselves.
+func NewRenderer() *Renderer {
+	r := Renderer{
+		types:    make(map[string]RenderFunc),
+		fallback: RenderNestedContent,
+	}
+
+	r.Register("core/text", RenderText)
+	r.Register("core/image", RenderImage)
+	r.Register("core/factbox", RenderFactbox)
+	r.Register("core/unordered-list", RenderUnorderedList)
+	r.Register("core/ordered-list", RenderOrderedList)
+	r.Register("core/blockquote", RenderBlockquote)
+
+	return &r
+}
+
+// Register sets the render function for a block type.
+func (r *Renderer) Register(blockType string, fn RenderFunc) {
+	r.types[blockType] = fn
+}
+
+// Unregister removes the render function for a block type, blocks of the type
+// will be passed to the fallback.
+func (r *Renderer) Unregister(blockType string) {
+	delete(r.types, blockType)
+}
+
+// Registered reports whether there is a render function for the block type.
+func (r *Renderer) Registered(blockType string) bool {
+	_, ok := r.types[blockType]
+
+	return ok
+}
+
+// SetFallback sets the render function that is used for blocks of types that
+// don't have a render function.
+func (r *Renderer) SetFallback(fn RenderFunc) {
+	r.fallback = fn
+}
+
+// RenderDocument renders the content of the document.
+func (r *Renderer) RenderDocument(out io.Writer, doc newsdoc.Document) error {
+	return r.Render(out, doc.Content)
+}
+
+// Render renders the blocks.
+func (r *Renderer) Render(out io.Writer, blocks []newsdoc.Block) error {
+	w := Writer{r: r, out: out}
+
+	w.Content(blocks)
+
+	return w.err
+}
+
+// Writer writes HTML for render functions. Write errors are kept and
+// returned by Renderer.Render(), and all writes after an error are ignored.
+type Writer struct {
+	r   *Renderer
+	out io.Writer
+	err error
+}
+
+// Attr is an HTML attribute.
+type Attr struct {
+	Name  string
+	Value string
+}
+
+// Raw writes HTML without escaping it.
+func (w *Writer) Raw(s string) {
+	if w.err != nil {
+		return
+	}
+
+	_, w.err = io.WriteString(w.out, s)
+}
+
+// Text writes escaped text.
+func (w *Writer) Text(s string) {
+	w.Raw(html.EscapeString(s))
+}
+
+// Inline writes text that can contain inline formatting, see
+// SanitizeInline().
+func (w *Writer) Inline(s string) {
+	w.Raw(SanitizeInline(s))
+}
+
+// Open writes a start tag. Attribute values are escaped, and href and src
+// attributes that aren't safe URLs are left out, see SafeURL().
+func (w *Writer) Open(tag string, attrs ...Attr) {
+	var sb strings.Builder
+
+	sb.WriteByte('<')
+	sb.WriteString(tag)
+
+	for _, a := range attrs {
+		if (a.Name == "href" || a.Name == "src") && !SafeURL(a.Value) {
+			continue
+		}
+
+		sb.WriteByte(' ')
+		sb.WriteString(a.Name)
+		sb.WriteString(`="`)
+		sb.WriteString(html.EscapeString(a.Value))
+		sb.WriteByte('"')
+	}
+
+	sb.WriteByte('>')
+
+	w.Raw(sb.String())
+}
+
+// Close writes an end tag.
+func (w *Writer) Close(tag string) {
+	w.Raw("</" + tag + ">")
+}
+
+// Element writes an element with inline content followed by a newline.
+func (w *Writer) Element(tag string, inline string, attrs ...Attr) {
+	w.Open(tag, attrs...)
+	w.Inline(inline)
+	w.Close(tag)
+	w.Raw("\n")
+}
+
+// Content renders nested blocks using the render functions of the renderer.
+func (w *Writer) Content(blocks []newsdoc.Block) {
+	for _, b := range blocks {
+		if w.err != nil {
+			return
+		}
+
+		fn, ok := w.r.types[b.Type]
+		if !ok {
+			fn = w.r.fallback
+		}
+
+		if fn == nil {
+			continue
+		}
+
+		fn(w, b)
+	}
+}
+
+// RenderNestedContent renders the nested content of a block, but nothing of
+// the block itself.
+func RenderNestedContent(w *Writer, b newsdoc.Block) {
+	w.Content(b.Content)
+}
+
+// RenderText renders core/text blocks. The roles "heading-1" to "heading-6"
+// are rendered as headings, "list-item" as list items and "preformatted" as
+// preformatted text. Other roles are rendered as paragraphs with the role as
+// class name. The text is read from data.text.
+func RenderText(w *Writer, b newsdoc.Block) {
+	text := b.Data.Get("text", "")
+	if text == "" {
+		return
+	}
+
+	switch b.Role {
+	case "list-item":
+		w.Element("li", text)
+
+		return
+	case "preformatted":
+		w.Element("pre", text)
+
+		return
+	}
+
+	level, ok := strings.CutPrefix(b.Role, "heading-")
+	if ok {
+		n, err := strconv.Atoi(level)
+		if err == nil && n >= 1 && n <= 6 {
+			w.Element("h"+level, text)
+
+			return
+		}
+	}
+
+	if b.Role == "" {
+		w.Element("p", text)
+
+		return
+	}
+
+	w.Element("p", text, Attr{Name: "class", Value: b.Role})
+}
+
+// RenderImage renders core/image blocks as figures. The image URL is read
+// from the block URL or data.url, the alt text from data.alt, and the caption
+// and credit from data.text and data.credit.
+func RenderImage(w *Writer, b newsdoc.Block) {
+	src := b.URL
+	if src == "" {
+		src = b.Data.Get("url", "")
+	}
+
+	if src == "" || !SafeURL(src) {
+		return
+	}
+
+	caption := b.Data.Get("text", "")
+	credit := b.Data.Get("credit", "")
+
+	w.Raw("<figure>\n")
+	w.Open("img",
+		Attr{Name: "src", Value: src},
+		Attr{Name: "alt", Value: b.Data.Get("alt", "")},
+	)
+	w.Raw("\n")
+
+	if caption != "" || credit != "" {
+		w.Open("figcaption")
+		w.Inline(caption)
+
+		if credit != "" {
+			if caption != "" {
+				w.Raw(" ")
+			}
+
+			w.Open("span", Attr{Name: "class", Value: "credit"})
+			w.Text(credit)
+			w.Close("span")
+		}
+
+		w.Close("figcaption")
+		w.Raw("\n")
+	}
+
+	w.Raw("</figure>\n")
+}
+
+// RenderUnorderedList renders core/unordered-list blocks as ul elements with
+// the nested content as list items.
+func RenderUnorderedList(w *Writer, b newsdoc.Block) {
+	renderContainer(w, "ul", b)
+}
+
+// RenderOrderedList renders core/ordered-list blocks as ol elements with the
+// nested content as list items.
+func RenderOrderedList(w *Writer, b newsdoc.Block) {
+	renderContainer(w, "ol", b)
+}
+
+// RenderBlockquote renders core/blockquote blocks as blockquote elements with
+// the nested content.
+func RenderBlockquote(w *Writer, b newsdoc.Block) {
+	renderContainer(w, "blockquote", b)
+}
+
+// renderContainer renders the nested content of the block in an element.
+// Blocks without nested content are left out.
+func renderContainer(w *Writer, tag string, b newsdoc.Block) {
+	if len(b.Content) == 0 {
+		return
+	}
+
+	w.Open(tag)
+	w.Raw("\n")
+	w.Content(b.Content)
+	w.Close(tag)
+	w.Raw("\n")
+}
+
+// RenderFactbox renders core/factbox blocks as asides with the title from
+// data.title or the block title, the text from data.text, and the nested
+// content.
+func RenderFactbox(w *Writer, b newsdoc.Block) {
+	title := b.Data.Get("title", b.Title)
+	text := b.Data.Get("text", "")
+
+	w.Raw(`<aside class="factbox">` + "\n")
+
+	if title != "" {
+		w.Element("h2", title)
+	}
+
+	if text != "" {
+		w.Element("p", text)
+	}
+
+	w.Content(b.Content)
+	w.Raw("</aside>\n")
+}
@@ -0,0 +1,122 @@
+package htmlcontent_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/htmlcontent"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func loadArticle(t *testing.T) newsdoc.Document {
+	t.Helper()
+
+	var doc newsdoc.Document
+
+	err := test.UnmarshalFile(filepath.Join("testdata", "article.json"), &doc)
+	test.Mustf(t, err, "unmarshal article")
+
+	return doc
+}
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := htmlcontent.NewRenderer().RenderDocument(&buf, loadArticle(t))
+	test.Mustf(t, err, "render document")
+
+	test.AgainstGoldenText(t, test.Regenerate(), buf.String(),
+		filepath.Join("testdata", t.Name(), "article.html"))
+}
+
+func TestRenderCustom(t *testing.T) {
+	r := htmlcontent.NewRenderer()
+
+	r.Register("example/embed", func(w *htmlcontent.Writer, b newsdoc.Block) {
+		w.Open("div", htmlcontent.Attr{Name: "class", Value: "embed"})
+		w.Text(b.Data.Get("text", ""))
+		w.Close("div")
+		w.Raw("\n")
+	})
+
+	var unknown []string
+
+	r.SetFallback(func(_ *htmlcontent.Writer, b newsdoc.Block) {
+		unknown = append(unknown, b.Type)
+	})
+
+	r.Unregister("core/image")
+
+	var buf bytes.Buffer
+
+	doc := loadArticle(t)
+
+	err := r.Render(&buf, doc.Content[4:])
+	test.Mustf(t, err, "render blocks")
+
+	want := `<h2>Looking ahead</h2>
+<ul>
+<li>The <em>semi-finals</em> start in April.</li>
+<li>Tickets go on sale on Monday.</li>
+</ul>
+<ol>
+<li>Home team</li>
+<li>Away team</li>
+</ol>
+<blockquote>
+<p>We never stopped believing.</p>
+</blockquote>
+<pre>1-0 (12:04)</pre>
+<aside class="factbox">
+<h2>Facts</h2>
+<p>The league was founded in 1922.</p>
+<p>Nested paragraph.</p>
+</aside>
+<div class="embed">Not rendered</div>
+`
+
+	test.EqualDiffWithOptionsf(t, want, buf.String(), nil, "rendered HTML")
+	test.EqualDiffWithOptionsf(t, []string{"core/image"}, unknown, nil,
+		"blocks passed to the fallback")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(_ []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestRenderWriteError(t *testing.T) {
+	err := htmlcontent.NewRenderer().RenderDocument(
+		failingWriter{}, loadArticle(t))
+	if err == nil {
+		t.Fatal("expected the write error to be returned")
+	}
+}
+
+func TestSanitizeInline(t *testing.T) {
+	cases := map[string]string{
+		"plain & simple":                         "plain &amp; simple",
+		"<strong>bold</strong>":                  "<strong>bold</strong>",
+		"<STRONG class='x'>bold</STRONG>":        "<strong>bold</strong>",
+		"<em>unclosed":                           "<em>unclosed</em>",
+		"<b><i>misnested</b></i>":                "<b><i>misnested</i></b>",
+		"<b>a<i>b<b>c</b>d</i></b>":              "<b>a<i>b<b>c</b>d</i></b>",
+		"<div>block</div>":                       "block",
+		"line<br/>break":                         "line<br>break",
+		"<script>alert(1)</script>text":          "text",
+		`<a href="/relative">rel</a>`:            `<a href="/relative">rel</a>`,
+		`<a href="javascript:x()">js</a>`:        `<a>js</a>`,
+		`<a href="https://x.com/?a=1&b=2">q</a>`: `<a href="https://x.com/?a=1&amp;b=2">q</a>`,
+	}
+
+	for in, want := range cases {
+		got := htmlcontent.SanitizeInline(in)
+		if got != want {
+			t.Errorf("SanitizeInline(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package newsdoc
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// BlockIDGenerator returns a new ID for the block at the path.
+type BlockIDGenerator func(path BlockPath, b Block) string
+
+// UUIDBlockIDs is a BlockIDGenerator that generates random (v4) UUIDs.
+func UUIDBlockIDs(_ BlockPath, _ Block) string {
+	return uuid.NewString()
+}
+
+// SequentialBlockIDs returns a BlockIDGenerator that generates IDs in the form
+// "{prefix}1", "{prefix}2", and so on.
+func SequentialBlockIDs(prefix string) BlockIDGenerator {
+	var n int
+
+	return func(_ BlockPath, _ Block) string {
+		n++
+
+		return prefix + strconv.Itoa(n)
+	}
+}
+
+// BlockIDOption is an option for EnsureBlockIDs().
+type BlockIDOption func(opts *blockIDOptions)
+
+type blockIDOptions struct {
+	rules []blockIDRule
+}
+
+type blockIDRule struct {
+	kind  BlockKind
+	types []string
+}
+
+// IDsFor restricts EnsureBlockIDs() to blocks of the given kind, and if any
+// types are given, to blocks of those types. The option can be given several
+// times to assign IDs to more kinds and types of blocks.
+func IDsFor(kind BlockKind, types ...string) BlockIDOption {
+	return func(opts *blockIDOptions) {
+		opts.rules = append(opts.rules, blockIDRule{
+			kind:  kind,
+			types: types,
+		})
+	}
+}
+
+func (opts blockIDOptions) wantsID(kind BlockKind, b Block) bool {
+	if len(opts.rules) == 0 {
+		return true
+	}
+
+	for _, r := range opts.rules {
+		if r.kind != kind {
+			continue
+		}
+
+		if len(r.types) == 0 || slices.Contains(r.types, b.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxIDAttempts is the number of times the generator is asked for a new ID
+// before EnsureBlockIDs() gives up on finding an unused ID.
+const maxIDAttempts = 10
+
+// EnsureBlockIDs assigns IDs to blocks anywhere in the document that don't
+// have one. All blocks get IDs unless the IDsFor() option is used. Generated
+// IDs that are empty or already in use in the document are discarded and the
+// generator is asked for another ID. Returns the number of assigned IDs. If
+// an error is returned the IDs assigned before the error are kept.
+func EnsureBlockIDs(
+	doc *Document, generator BlockIDGenerator, options ...BlockIDOption,
+) (int, error) {
+	var opts blockIDOptions
+
+	for _, o := range options {
+		o(&opts)
+	}
+
+	used := make(map[string]bool)
+
+	inspectDocument(*doc, newDeepOptions(nil), func(_ BlockPath, b *Block) walkAction {
+		if b.ID != "" {
+			used[b.ID] = true
+		}
+
+		return walkContinue
+	})
+
+	var (
+		n   int
+		err error
+	)
+
+	walkDocument(doc, newDeepOptions(nil), func(path BlockPath, b *Block) walkAction {
+		if b.ID != "" || !opts.wantsID(path[len(path)-1].Kind, *b) {
+			return walkContinue
+		}
+
+		for range maxIDAttempts {
+			id := generator(path, *b)
+			if id == "" || used[id] {
+				continue
+			}
+
+			used[id] = true
+			b.ID = id
+			n++
+
+			return walkContinue
+		}
+
+		err = fmt.Errorf("failed to generate an unused ID for the block at %s", path)
+
+		return walkStop
+	})
+
+	return n, err
+}
+
+// DuplicateBlockID is a block ID that is used by more than one block.
+type DuplicateBlockID struct {
+	ID    string
+	Paths []BlockPath
+}
+
+// CheckBlockIDs reports block IDs that are used by more than one block
+// anywhere in the document. The duplicates are listed in the order they first
+// appear in.
+func CheckBlockIDs(doc Document) []DuplicateBlockID {
+	var (
+		order []string
+		paths = make(map[string][]BlockPath)
+	)
+
+	inspectDocument(doc, newDeepOptions(nil), func(path BlockPath, b *Block) walkAction {
+		if b.ID == "" {
+			return walkContinue
+		}
+
+		if _, seen := paths[b.ID]; !seen {
+			order = append(order, b.ID)
+		}
+
+		paths[b.ID] = append(paths[b.ID], path)
+
+		return walkContinue
+	})
+
+	var dupes []DuplicateBlockID
+
+	for _, id := range order {
+		if len(paths[id]) < 2 {
+			continue
+		}
+
+		dupes = append(dupes, DuplicateBlockID{
+			ID:    id,
+			Paths: paths[id],
+		})
+	}
+
+	return dupes
+}
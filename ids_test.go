@@ -0,0 +1,123 @@
+package newsdoc_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func blockIDs(doc newsdoc.Document) []string {
+	var ids []string
+
+	for _, m := range newsdoc.AllBlocksDeep(doc, newsdoc.BlockMatchFunc(
+		func(_ newsdoc.Block) bool { return true },
+	)) {
+		ids = append(ids, m.Path.String()+"="+m.Block.ID)
+	}
+
+	return ids
+}
+
+func TestEnsureBlockIDs(t *testing.T) {
+	doc := deepDocument()
+	doc.Content[0].ID = "id-2"
+
+	n, err := newsdoc.EnsureBlockIDs(&doc, newsdoc.SequentialBlockIDs("id-"))
+	test.Mustf(t, err, "ensure block IDs")
+
+	if n != 9 {
+		t.Errorf("expected 9 assigned IDs, got %d", n)
+	}
+
+	// The generated "id-2" is skipped as it's already in use.
+	test.EqualDiffWithOptionsf(t, []string{
+		"content[0]=id-2",
+		"content[1]=id-1",
+		"content[2]=id-3",
+		"content[2].content[0]=id-4",
+		"content[2].content[1]=id-5",
+		"meta[0]=id-6",
+		"meta[0].meta[0]=id-7",
+		"meta[0].links[0]=id-8",
+		"links[0]=id-9",
+		"links[1]=id-10",
+	}, blockIDs(doc), nil, "block IDs")
+
+	if dupes := newsdoc.CheckBlockIDs(doc); len(dupes) != 0 {
+		t.Errorf("expected no duplicate IDs, got %v", dupes)
+	}
+}
+
+func TestEnsureBlockIDsRules(t *testing.T) {
+	doc := deepDocument()
+
+	n, err := newsdoc.EnsureBlockIDs(&doc, newsdoc.UUIDBlockIDs,
+		newsdoc.IDsFor(newsdoc.BlockKindMeta, "core/assignment"),
+		newsdoc.IDsFor(newsdoc.BlockKindLinks))
+	test.Mustf(t, err, "ensure block IDs")
+
+	if n != 4 {
+		t.Errorf("expected 4 assigned IDs, got %d", n)
+	}
+
+	var withID []string
+
+	for _, m := range newsdoc.AllBlocksDeep(doc, newsdoc.BlockMatchFunc(
+		func(b newsdoc.Block) bool { return b.ID != "" },
+	)) {
+		err := uuid.Validate(m.Block.ID)
+		test.Mustf(t, err, "validate ID of block at %s", m.Path)
+
+		withID = append(withID, m.Path.String())
+	}
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"meta[0]", "meta[0].links[0]", "links[0]", "links[1]",
+	}, withID, nil, "blocks with IDs")
+}
+
+func TestEnsureBlockIDsExhausted(t *testing.T) {
+	doc := deepDocument()
+
+	_, err := newsdoc.EnsureBlockIDs(&doc,
+		func(_ newsdoc.BlockPath, _ newsdoc.Block) string {
+			return "same"
+		})
+	if err == nil {
+		t.Fatal("expected an error when the generator only returns used IDs")
+	}
+
+	if doc.Content[0].ID != "same" || doc.Content[1].ID != "" {
+		t.Errorf("expected only the first block to get an ID, got %v",
+			blockIDs(doc))
+	}
+}
+
+func TestCheckBlockIDs(t *testing.T) {
+	doc := deepDocument()
+
+	doc.Content[0].ID = "a"
+	doc.Content[2].Content[1].ID = "b"
+	doc.Meta[0].Links[0].ID = "a"
+	doc.Links[1].ID = "b"
+	doc.Links[0].ID = "c"
+
+	dupes := newsdoc.CheckBlockIDs(doc)
+
+	var got []string
+
+	for _, d := range dupes {
+		for _, p := range d.Paths {
+			got = append(got, d.ID+"@"+p.String())
+		}
+	}
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"a@content[0]",
+		"a@meta[0].links[0]",
+		"b@content[2].content[1]",
+		"b@links[1]",
+	}, got, nil, "duplicate IDs")
+}
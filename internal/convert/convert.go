@@ -0,0 +1,32 @@
+// Package convert has the types that are shared by the format converters.
+package convert
+
+import "fmt"
+
+// LinkType is the type and rel of a link.
+type LinkType struct {
+	Type string `json:"type"`
+	Rel  string `json:"rel"`
+}
+
+// Lost is a field, element or block that couldn't be converted.
+type Lost struct {
+	// Path is the location in the source, in the notation of the format,
+	// f.ex. "subjects[0].broader" for a ninjs field, or "links[2]" for a
+	// document block.
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Report lists what was lost in a conversion.
+type Report struct {
+	Lost []Lost `json:"lost,omitempty"`
+}
+
+// Add adds a lost item to the report.
+func (r *Report) Add(path string, format string, a ...any) {
+	r.Lost = append(r.Lost, Lost{
+		Path:   path,
+		Reason: fmt.Sprintf(format, a...),
+	})
+}
@@ -37,6 +37,23 @@ func (fi SortMapStringKeys) JSONTransform(_ map[string]any) error {
 	return nil
 }
 
+var _ GoldenHelper = EquateEmpty{}
+
+// EquateEmpty treats nil and empty slices and maps as equal.
+type EquateEmpty struct{}
+
+// CmpOpts implements GoldenHelper.
+func (fi EquateEmpty) CmpOpts() cmp.Options {
+	return cmp.Options{
+		cmpopts.EquateEmpty(),
+	}
+}
+
+// JSONTransform implements GoldenHelper.
+func (fi EquateEmpty) JSONTransform(_ map[string]any) error {
+	return nil
+}
+
 // AgainstGolden compares a result against the contents of the file at the
 // goldenPath. Run with regenerate set to true to create or update the file.
 func AgainstGolden[T any](
@@ -115,6 +132,28 @@ func AgainstGolden[T any](
 		"must match golden file %q", goldenPath)
 }
 
+// AgainstGoldenText compares text against the contents of the file at the
+// goldenPath. Run with regenerate set to true to create or update the file.
+func AgainstGoldenText(
+	t TestingT,
+	regenerate bool,
+	got string,
+	goldenPath string,
+) {
+	t.Helper()
+
+	if regenerate {
+		err := os.WriteFile(goldenPath, []byte(got), 0o600)
+		Mustf(t, err, "write golden file %q", goldenPath)
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	Mustf(t, err, "read from golden file %q", goldenPath)
+
+	EqualDiffWithOptionsf(t, string(want), got, nil,
+		"must match golden file %q", goldenPath)
+}
+
 // EqualDiffWithOptionsf runs a cmp.Diff with protobuf-specific options.
 func EqualDiffWithOptionsf[T any](
 	t TestingT,
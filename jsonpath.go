@@ -0,0 +1,356 @@
+package newsdoc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupportedJSONPath is returned when an extractor uses a construct that
+// can't be expressed as a JSONPath query.
+var ErrUnsupportedJSONPath = errors.New("can't be expressed as JSONPath")
+
+// JSONPathQuery is a RFC 9535 JSONPath query for one of the values of a
+// ValueExtractor.
+type JSONPathQuery struct {
+	// Name is the name of the value, or the name of the block for block
+	// extraction.
+	Name       string
+	Query      string
+	Optional   bool   `json:",omitempty"`
+	Annotation string `json:",omitempty"`
+	Role       string `json:",omitempty"`
+}
+
+// JSONPath translates the extractor to JSONPath queries, one for each
+// extracted value. The queries select the same values as Collect() does,
+// including the all-or-nothing semantics for required values, but as separate
+// node lists, so the grouping of values into rows is lost.
+//
+// Document attribute extraction can't filter on the document itself, so only
+// single value document expressions can be translated. These queries will
+// return empty values if the document has them, something that will never
+// happen for documents that have been marshalled by this package.
+func (ve *ValueExtractor) JSONPath() ([]JSONPathQuery, error) {
+	if len(ve.Selectors) == 0 {
+		return ve.documentJSONPath()
+	}
+
+	var base strings.Builder
+
+	base.WriteString("$")
+
+	last := len(ve.Selectors) - 1
+
+	for _, sel := range ve.Selectors[:last] {
+		err := writeJSONPathSegment(&base, sel, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var conds []string
+
+	if len(ve.ChildSelectors) > 0 {
+		var child strings.Builder
+
+		child.WriteString("@")
+
+		for _, sel := range ve.ChildSelectors {
+			err := writeJSONPathSegment(&child, sel, nil)
+			if err != nil {
+				return nil, fmt.Errorf("child selectors: %w", err)
+			}
+		}
+
+		conds = append(conds, child.String())
+	}
+
+	if ve.ValueKind == ValueKindBlock {
+		err := writeJSONPathSegment(&base, ve.Selectors[last], conds)
+		if err != nil {
+			return nil, err
+		}
+
+		spec := ve.Values[0]
+
+		return []JSONPathQuery{{
+			Name:       spec.Name,
+			Query:      base.String(),
+			Annotation: spec.Annotation,
+		}}, nil
+	}
+
+	valuePaths := make([]string, len(ve.Values))
+
+	for i, v := range ve.Values {
+		p, err := ve.jsonPathValue(v)
+		if err != nil {
+			return nil, err
+		}
+
+		valuePaths[i] = p
+
+		if !v.Optional {
+			conds = append(conds, jsonPathNonEmpty("@"+p))
+		}
+	}
+
+	queries := make([]JSONPathQuery, len(ve.Values))
+
+	for i, v := range ve.Values {
+		valueConds := conds
+
+		// Optional values are skipped when they're empty.
+		if v.Optional {
+			valueConds = append(valueConds[:len(valueConds):len(valueConds)],
+				jsonPathNonEmpty("@"+valuePaths[i]))
+		}
+
+		var q strings.Builder
+
+		q.WriteString(base.String())
+
+		err := writeJSONPathSegment(&q, ve.Selectors[last], valueConds)
+		if err != nil {
+			return nil, err
+		}
+
+		q.WriteString(valuePaths[i])
+
+		queries[i] = JSONPathQuery{
+			Name:       v.Name,
+			Query:      q.String(),
+			Optional:   v.Optional,
+			Annotation: v.Annotation,
+			Role:       v.Role,
+		}
+	}
+
+	return queries, nil
+}
+
+func (ve *ValueExtractor) documentJSONPath() ([]JSONPathQuery, error) {
+	if len(ve.Values) > 1 {
+		return nil, fmt.Errorf(
+			"extraction of multiple document attributes %w",
+			ErrUnsupportedJSONPath)
+	}
+
+	queries := make([]JSONPathQuery, 0, len(ve.Values))
+
+	for _, v := range ve.Values {
+		switch documentAttributeKey(v.Name) {
+		case docAttrType, docAttrLanguage, docAttrTitle,
+			docAttrUUID, docAttrURI, docAttrURL:
+		default:
+			return nil, fmt.Errorf(
+				"unknown document attribute %q", v.Name)
+		}
+
+		queries = append(queries, JSONPathQuery{
+			Name:       v.Name,
+			Query:      "$" + jsonPathMember(v.Name),
+			Optional:   v.Optional,
+			Annotation: v.Annotation,
+			Role:       v.Role,
+		})
+	}
+
+	return queries, nil
+}
+
+// jsonPathValue returns the relative path to the value for a value spec.
+func (ve *ValueExtractor) jsonPathValue(v ValueSpec) (string, error) {
+	source := v.Source
+
+	switch ve.ValueKind {
+	case ValueKindAttributes:
+		source = ValueSourceAttributes
+	case ValueKindData:
+		source = ValueSourceData
+	case ValueKindBlock, ValueKindCombined:
+	}
+
+	switch source {
+	case ValueSourceAttributes:
+		if !isBlockAttribute(v.Name) {
+			return "", fmt.Errorf(
+				"unknown block attribute %q", v.Name)
+		}
+
+		return jsonPathMember(v.Name), nil
+	case ValueSourceData:
+		return ".data" + jsonPathMember(v.Name), nil
+	}
+
+	return "", fmt.Errorf("unknown value source %q for %q", source, v.Name)
+}
+
+// writeJSONPathSegment writes the child segment for a selector, with the
+// selector filter and any additional conditions.
+func writeJSONPathSegment(
+	buf *strings.Builder, sel BlockSelector, conds []string,
+) error {
+	switch sel.Kind {
+	case BlockKindMeta, BlockKindLinks, BlockKindContent:
+	default:
+		return fmt.Errorf("unknown block kind: %s", sel.Kind)
+	}
+
+	buf.WriteString(jsonPathMember(string(sel.Kind)))
+
+	var exprs []string
+
+	if sel.Filter != nil {
+		expr, err := jsonPathFilter(sel.Filter)
+		if err != nil {
+			return err
+		}
+
+		if sel.Filter.Op == FilterOpOr && len(conds) > 0 {
+			expr = "(" + expr + ")"
+		}
+
+		exprs = append(exprs, expr)
+	}
+
+	exprs = append(exprs, conds...)
+
+	if len(exprs) == 0 {
+		buf.WriteString("[*]")
+
+		return nil
+	}
+
+	buf.WriteString("[?")
+	buf.WriteString(strings.Join(exprs, " && "))
+	buf.WriteString("]")
+
+	return nil
+}
+
+// jsonPathFilter translates a filter node to a JSONPath logical expression.
+func jsonPathFilter(fn *FilterNode) (string, error) {
+	switch fn.Op {
+	case FilterOpAnd, FilterOpOr:
+		parts := make([]string, len(fn.Children))
+
+		for i := range fn.Children {
+			child := &fn.Children[i]
+
+			expr, err := jsonPathFilter(child)
+			if err != nil {
+				return "", err
+			}
+
+			if fn.Op == FilterOpAnd && child.Op == FilterOpOr {
+				expr = "(" + expr + ")"
+			}
+
+			parts[i] = expr
+		}
+
+		sep := " && "
+		if fn.Op == FilterOpOr {
+			sep = " || "
+		}
+
+		return strings.Join(parts, sep), nil
+	}
+
+	if fn.Data != nil {
+		path := "@.data" + jsonPathMember(fn.Data.Key)
+
+		switch fn.Data.Mode {
+		case DataFilterExact:
+			return jsonPathEquals(path, fn.Data.Value), nil
+		case DataFilterExists:
+			return path, nil
+		case DataFilterNonEmpty:
+			return jsonPathNonEmpty(path), nil
+		}
+
+		return "", fmt.Errorf("unknown data filter mode %q", fn.Data.Mode)
+	}
+
+	if err := validateAttributeKey(fn.Attr); err != nil {
+		return "", err
+	}
+
+	return jsonPathEquals("@"+jsonPathMember(fn.Attr), fn.Value), nil
+}
+
+// jsonPathEquals returns a comparison expression. Missing values are treated
+// as empty strings, so a comparison with an empty string also matches missing
+// members.
+func jsonPathEquals(path string, value string) string {
+	if value == "" {
+		return "(!" + path + " || " + path + " == '')"
+	}
+
+	return path + " == " + jsonPathString(value)
+}
+
+func jsonPathNonEmpty(path string) string {
+	return path + " && " + path + " != ''"
+}
+
+// jsonPathMember returns a child segment for a member name, using the
+// shorthand form when possible.
+func jsonPathMember(name string) string {
+	shorthand := name != ""
+
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			shorthand = false
+		}
+	}
+
+	if shorthand {
+		return "." + name
+	}
+
+	return "[" + jsonPathString(name) + "]"
+}
+
+// jsonPathString returns a single-quoted JSONPath string literal.
+func jsonPathString(s string) string {
+	var sb strings.Builder
+
+	sb.WriteByte('\'')
+
+	for _, r := range s {
+		switch r {
+		case '\'':
+			sb.WriteString(`\'`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&sb, `\u%04x`, r)
+
+				continue
+			}
+
+			sb.WriteRune(r)
+		}
+	}
+
+	sb.WriteByte('\'')
+
+	return sb.String()
+}
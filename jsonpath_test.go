@@ -0,0 +1,166 @@
+package newsdoc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/theory/jsonpath"
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func TestValueExtractorJSONPath(t *testing.T) {
+	cases := map[string][]string{
+		".meta(type='core/planning-item').data{start_date, date_tz?}": {
+			"$.meta[?@.type == 'core/planning-item' && @.data.start_date && @.data.start_date != ''].data.start_date",
+			"$.meta[?@.type == 'core/planning-item' && @.data.start_date && @.data.start_date != '' && @.data.date_tz && @.data.date_tz != ''].data.date_tz",
+		},
+		"items=.meta(type='core/a').links(rel='item' data.x?)": {
+			"$.meta[?@.type == 'core/a'].links[?@.rel == 'item' && @.data.x]",
+		},
+		"a=.meta(value='x' or value='y')#.links(rel='d')": {
+			"$.meta[?(@.value == 'x' || @.value == 'y') && @.links[?@.rel == 'd']]",
+		},
+		".links(type='' data.dash-key='it\\'s')@{uuid}": {
+			"$.links[?(!@.type || @.type == '') && @.data['dash-key'] == 'it\\'s' && @.uuid && @.uuid != ''].uuid",
+		},
+		"@{title}": {
+			"$.title",
+		},
+	}
+
+	for exp, want := range cases {
+		ve, err := newsdoc.ValueExtractorFromString(exp)
+		test.Mustf(t, err, "parse expression %q", exp)
+
+		queries, err := ve.JSONPath()
+		test.Mustf(t, err, "translate %q", exp)
+
+		var got []string
+
+		for _, q := range queries {
+			got = append(got, q.Query)
+
+			_, err := jsonpath.Parse(q.Query)
+			test.Mustf(t, err, "parse JSONPath %q", q.Query)
+		}
+
+		test.EqualDiffWithOptionsf(t, want, got, nil,
+			"queries for %q", exp)
+	}
+}
+
+func TestValueExtractorJSONPathUnsupported(t *testing.T) {
+	ve, err := newsdoc.ValueExtractorFromString("@{title uuid}")
+	test.Mustf(t, err, "parse expression")
+
+	_, err = ve.JSONPath()
+	if !errors.Is(err, newsdoc.ErrUnsupportedJSONPath) {
+		t.Fatalf("expected ErrUnsupportedJSONPath, got %v", err)
+	}
+
+	ve, err = newsdoc.ValueExtractorFromString(".meta@{nonesuch}")
+	test.Mustf(t, err, "parse expression")
+
+	_, err = ve.JSONPath()
+	if err == nil {
+		t.Fatal("expected an error for an unknown attribute")
+	}
+}
+
+// TestValueExtractorJSONPathEquivalence evaluates the translated queries
+// against the extractor testdata and compares the results with Collect().
+func TestValueExtractorJSONPathEquivalence(t *testing.T) {
+	dataDir := filepath.Join("testdata", "TestValueExtractor")
+
+	expressions := []string{
+		"@{title}",
+		".meta(type='example/collection').links(rel='item').data{date:date, tz=date_timezone?}",
+		".meta(type='example/collection').links(rel='item').data{start, end}",
+		".content(type='example/assumed-static-tz')@{value:date}",
+		".links(rel='point-in-time' type='example/pit').data{timestamp}",
+		"pointy=.links(rel='point-in-time' type='example/pit'):interesting",
+		".meta(type='example/collection').links(rel='item' data.date_timezone='Asia/Shanghai').data{date:date}",
+		".meta(type='example/collection').links(rel='item' data.date?).data{date:date}",
+		".meta(type='example/collection').links(rel='item' data.date??).data{date:date}",
+		".meta(type='core/planning-item').data{start_date, date_tz?}",
+		".meta(type='core/assignment').links(rel='deliverable')@{uuid}",
+		"block=.meta(type='core/assignment').links(rel='deliverable' data.nonesuch='value')",
+		"assignment=.meta(type='core/assignment')#.links(rel='deliverable' uuid='4f13347f-04b3-4f22-a992-9316d824b81f')",
+		".meta(type='core/assignment')@{id}#.links(rel='deliverable' uuid='4f13347f-04b3-4f22-a992-9316d824b81f')",
+		".meta(type='core/assignment')@{title}.data{start_date date_tz}",
+		".meta(type='core/assignment' (data.public='true' or data.public='yes'))#.meta(type='core/assignment-type')@{title rel?}",
+		".links(role='' (rel='event' or rel='section'))@{uuid title}",
+		".meta(type='core/description' role='internal').data{text}",
+	}
+
+	for _, name := range []string{"planning.json", "constructed.json"} {
+		t.Run(name, func(t *testing.T) {
+			var doc newsdoc.Document
+
+			err := test.UnmarshalFile(filepath.Join(dataDir, name), &doc)
+			test.Mustf(t, err, "unmarshal document")
+
+			data, err := json.Marshal(doc)
+			test.Mustf(t, err, "marshal document")
+
+			var value any
+
+			err = json.Unmarshal(data, &value)
+			test.Mustf(t, err, "unmarshal document as JSON value")
+
+			for _, exp := range expressions {
+				ve, err := newsdoc.ValueExtractorFromString(exp)
+				test.Mustf(t, err, "parse expression %q", exp)
+
+				queries, err := ve.JSONPath()
+				test.Mustf(t, err, "translate %q", exp)
+
+				rows := ve.Collect(doc)
+
+				for _, q := range queries {
+					var want []any
+
+					for _, row := range rows {
+						v, ok := row[q.Name]
+						if !ok {
+							continue
+						}
+
+						if v.Block != nil {
+							want = append(want, jsonValue(t, v.Block))
+
+							continue
+						}
+
+						want = append(want, v.Value)
+					}
+
+					got := []any(jsonpath.MustParse(q.Query).Select(value))
+
+					test.EqualDiffWithOptionsf(t, want, got,
+						cmp.Options{cmpopts.EquateEmpty()},
+						"results of %q for %q", q.Query, exp)
+				}
+			}
+		})
+	}
+}
+
+func jsonValue(t *testing.T, v any) any {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	test.Mustf(t, err, "marshal value")
+
+	var value any
+
+	err = json.Unmarshal(data, &value)
+	test.Mustf(t, err, "unmarshal value")
+
+	return value
+}
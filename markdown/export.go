@@ -0,0 +1,518 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/htmlcontent"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Export converts content blocks to CommonMark. The inline HTML in data.text
+// is converted to Markdown emphasis, code spans and links, other allowed
+// inline elements are kept as raw HTML. Blocks of types that aren't in the
+// configuration don't produce any output of their own, but their nested
+// content is exported.
+func Export(blocks []newsdoc.Block, cfg Config) ([]byte, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	exp := exporter{cfg: cfg}
+
+	lines := exp.content(blocks)
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+type exporter struct {
+	cfg Config
+}
+
+// content returns the lines of the blocks, separated by blank lines.
+func (exp *exporter) content(blocks []newsdoc.Block) []string {
+	var (
+		res      []string
+		lastList BlockType
+		alt      bool
+	)
+
+	for _, b := range blocks {
+		// Adjacent lists of the same kind would be joined into one list
+		// when parsed, alternate between list markers to keep them
+		// apart.
+		list, isList := exp.listType(b)
+
+		switch {
+		case isList && list == lastList:
+			alt = !alt
+		default:
+			alt = false
+		}
+
+		lines := exp.block(b, alt)
+		if len(lines) == 0 {
+			continue
+		}
+
+		if isList {
+			lastList = list
+		} else {
+			lastList = BlockType{}
+		}
+
+		if len(res) > 0 {
+			res = append(res, "")
+		}
+
+		res = append(res, lines...)
+	}
+
+	return res
+}
+
+func (exp *exporter) listType(b newsdoc.Block) (BlockType, bool) {
+	switch {
+	case exp.cfg.UnorderedList.Matches(b):
+		return exp.cfg.UnorderedList, true
+	case exp.cfg.OrderedList.Matches(b):
+		return exp.cfg.OrderedList, true
+	}
+
+	return BlockType{}, false
+}
+
+func (exp *exporter) block(b newsdoc.Block, alt bool) []string {
+	for i, h := range exp.cfg.Headings {
+		if !h.Matches(b) {
+			continue
+		}
+
+		text := strings.Join(exp.textLines(b), " ")
+		if text == "" {
+			return nil
+		}
+
+		return []string{strings.Repeat("#", i+1) + " " + escapeClosingHashes(text)}
+	}
+
+	switch {
+	case exp.cfg.Paragraph.Matches(b):
+		return exp.textLines(b)
+	case exp.cfg.UnorderedList.Matches(b):
+		marker := "-"
+		if alt {
+			marker = "*"
+		}
+
+		return exp.list(b, func(_ int) string { return marker })
+	case exp.cfg.OrderedList.Matches(b):
+		delim := "."
+		if alt {
+			delim = ")"
+		}
+
+		start, err := strconv.Atoi(b.Data.Get("start", "1"))
+		if err != nil || start < 0 {
+			start = 1
+		}
+
+		return exp.list(b, func(i int) string {
+			return strconv.Itoa(start+i) + delim
+		})
+	case exp.cfg.BlockQuote.Matches(b):
+		return prefixLines(exp.content(b.Content), "> ", ">")
+	case exp.cfg.Image.Matches(b):
+		return exp.image(b)
+	case exp.cfg.CodeBlock.Matches(b):
+		return codeBlock(
+			html.UnescapeString(b.Data.Get("text", "")),
+			b.Data.Get("language", ""))
+	}
+
+	return exp.content(b.Content)
+}
+
+// textLines returns the Markdown lines for the text of a block.
+func (exp *exporter) textLines(b newsdoc.Block) []string {
+	text := inlineMarkdown(b.Data.Get("text", ""))
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.Split(text, "\n")
+
+	for i := range lines {
+		lines[i] = escapeLineStart(strings.TrimLeft(lines[i], " "))
+	}
+
+	return lines
+}
+
+func (exp *exporter) list(b newsdoc.Block, marker func(i int) string) []string {
+	var (
+		res   []string
+		loose bool
+	)
+
+	items := make([][]string, len(b.Content))
+
+	for i, item := range b.Content {
+		m := marker(i)
+
+		var lines []string
+
+		if exp.cfg.ListItem.Matches(item) {
+			lines = exp.textLines(item)
+
+			content := exp.content(item.Content)
+
+			// Nested lists can follow the text of the item directly,
+			// other blocks would be read as a continuation of the
+			// text.
+			if len(lines) > 0 && len(content) > 0 {
+				_, nestedList := exp.listType(item.Content[0])
+				if !nestedList {
+					lines = append(lines, "")
+				}
+			}
+
+			lines = append(lines, content...)
+		} else {
+			lines = exp.block(item, false)
+		}
+
+		if len(lines) == 0 {
+			items[i] = []string{m}
+
+			continue
+		}
+
+		loose = loose || slices.Contains(lines, "")
+
+		indent := strings.Repeat(" ", len(m)+1)
+
+		items[i] = append([]string{m + " " + lines[0]},
+			prefixLines(lines[1:], indent, "")...)
+	}
+
+	// Items are separated by blank lines if any of them contains blank
+	// lines, so that the list stays readable.
+	for i, lines := range items {
+		if loose && i > 0 {
+			res = append(res, "")
+		}
+
+		res = append(res, lines...)
+	}
+
+	return res
+}
+
+func (exp *exporter) image(b newsdoc.Block) []string {
+	src := b.URL
+	if src == "" {
+		src = b.Data.Get("url", "")
+	}
+
+	if src == "" {
+		return nil
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("![")
+	sb.WriteString(escapeText(b.Data.Get("alt", "")))
+	sb.WriteString("](")
+	sb.WriteString(destination(src))
+
+	title := b.Data.Get("text", "")
+	if title != "" {
+		sb.WriteString(` "`)
+		sb.WriteString(strings.NewReplacer(
+			`\`, `\\`, `"`, `\"`, "&", `\&`, "\n", " ",
+		).Replace(title))
+		sb.WriteByte('"')
+	}
+
+	sb.WriteByte(')')
+
+	return []string{sb.String()}
+}
+
+func codeBlock(text string, language string) []string {
+	fence := strings.Repeat("`", max(3, longestRun(text, '`')+1))
+
+	res := []string{fence + language}
+
+	if text != "" {
+		res = append(res, strings.Split(text, "\n")...)
+	}
+
+	return append(res, fence)
+}
+
+// prefixLines prefixes all lines, empty lines get the empty prefix.
+func prefixLines(lines []string, prefix string, empty string) []string {
+	res := make([]string, len(lines))
+
+	for i, l := range lines {
+		if l == "" {
+			res[i] = empty
+
+			continue
+		}
+
+		res[i] = prefix + l
+	}
+
+	return res
+}
+
+type inlineFrame struct {
+	tag  atom.Atom
+	href string
+	buf  strings.Builder
+}
+
+// inlineMarkdown converts inline HTML to Markdown. Line breaks are returned
+// as a backslash followed by a newline.
+func inlineMarkdown(text string) string {
+	stack := []*inlineFrame{{}}
+
+	inCode := func() bool {
+		for _, f := range stack {
+			if f.tag == atom.Code {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	pop := func() {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		stack[len(stack)-1].buf.WriteString(wrapInline(f))
+	}
+
+	z := xhtml.NewTokenizer(strings.NewReader(
+		htmlcontent.SanitizeInline(text)))
+
+	for {
+		tt := z.Next()
+
+		top := stack[len(stack)-1]
+
+		switch tt {
+		case xhtml.ErrorToken:
+			for len(stack) > 1 {
+				pop()
+			}
+
+			return trimTrailingBreaks(stack[0].buf.String())
+		case xhtml.TextToken:
+			t := strings.ReplaceAll(string(z.Text()), "\n", " ")
+
+			if inCode() {
+				top.buf.WriteString(t)
+			} else {
+				top.buf.WriteString(escapeText(t))
+			}
+		case xhtml.StartTagToken:
+			tok := z.Token()
+
+			if tok.DataAtom == atom.Br {
+				if inCode() {
+					top.buf.WriteString(" ")
+				} else {
+					top.buf.WriteString("\\\n")
+				}
+
+				continue
+			}
+
+			f := inlineFrame{tag: tok.DataAtom}
+
+			for _, a := range tok.Attr {
+				if a.Key == "href" {
+					f.href = a.Val
+				}
+			}
+
+			stack = append(stack, &f)
+		case xhtml.EndTagToken:
+			if len(stack) > 1 {
+				pop()
+			}
+		case xhtml.SelfClosingTagToken, xhtml.CommentToken,
+			xhtml.DoctypeToken:
+		}
+	}
+}
+
+// trimTrailingBreaks removes trailing line breaks and spaces, a backslash at
+// the end of a paragraph isn't a line break.
+func trimTrailingBreaks(s string) string {
+	for {
+		t := strings.TrimRight(s, " ")
+
+		t, ok := strings.CutSuffix(t, "\\\n")
+		if !ok {
+			return t
+		}
+
+		s = t
+	}
+}
+
+func wrapInline(f *inlineFrame) string {
+	inner := f.buf.String()
+
+	switch f.tag {
+	case atom.Strong, atom.B:
+		return wrapDelimiter(inner, "**")
+	case atom.Em, atom.I:
+		return wrapDelimiter(inner, "*")
+	case atom.Code:
+		return codeSpan(inner)
+	case atom.A:
+		if f.href == "" {
+			return inner
+		}
+
+		return "[" + inner + "](" + destination(f.href) + ")"
+	}
+
+	name := f.tag.String()
+
+	return "<" + name + ">" + inner + "</" + name + ">"
+}
+
+// wrapDelimiter wraps text in emphasis delimiters, leading and trailing
+// whitespace is moved outside the delimiters as they otherwise wouldn't be
+// recognised as emphasis.
+func wrapDelimiter(text string, delim string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+
+	start := strings.Index(text, trimmed)
+	end := start + len(trimmed)
+
+	return text[:start] + delim + trimmed + delim + text[end:]
+}
+
+func codeSpan(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	fence := strings.Repeat("`", longestRun(text, '`')+1)
+
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") ||
+		(strings.HasPrefix(text, " ") && strings.HasSuffix(text, " ") &&
+			strings.TrimSpace(text) != "") {
+		text = " " + text + " "
+	}
+
+	return fence + text + fence
+}
+
+func longestRun(s string, c byte) int {
+	var longest, n int
+
+	for i := range len(s) {
+		if s[i] != c {
+			n = 0
+
+			continue
+		}
+
+		n++
+		longest = max(longest, n)
+	}
+
+	return longest
+}
+
+// destination formats a link destination, destinations with spaces are
+// enclosed in angle brackets.
+func destination(u string) string {
+	if strings.ContainsAny(u, " \t\n") {
+		return "<" + strings.NewReplacer(
+			`\`, `\\`, "&", `\&`, "<", `\<`, ">", `\>`, "\n", "%0A",
+		).Replace(u) + ">"
+	}
+
+	return strings.NewReplacer(
+		`\`, `\\`, "&", `\&`, "(", `\(`, ")", `\)`, "<", `\<`, ">", `\>`,
+	).Replace(u)
+}
+
+// escapeText escapes characters that have a special meaning in running text.
+func escapeText(s string) string {
+	var sb strings.Builder
+
+	for i := range len(s) {
+		c := s[i]
+
+		switch c {
+		case '\\', '`', '*', '_', '[', ']', '<':
+			sb.WriteByte('\\')
+		case '&':
+			// Only escape ampersands that would be read as the start
+			// of a character reference.
+			if entityExp.MatchString(s[i:]) {
+				sb.WriteByte('\\')
+			}
+		}
+
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
+
+var orderedMarkerExp = regexp.MustCompile(`^([0-9]{1,9})([.)])`)
+
+// escapeLineStart escapes characters at the start of a line that would
+// otherwise start a block.
+func escapeLineStart(line string) string {
+	if line == "" {
+		return line
+	}
+
+	switch line[0] {
+	case '#', '>', '-', '+', '=', '~':
+		return `\` + line
+	}
+
+	if m := orderedMarkerExp.FindStringSubmatch(line); m != nil {
+		return m[1] + `\` + line[len(m[1]):]
+	}
+
+	return line
+}
+
+// escapeClosingHashes escapes a trailing sequence of hashes in a heading, as
+// it otherwise would be read as a closing sequence.
+func escapeClosingHashes(text string) string {
+	trimmed := strings.TrimRight(text, "#")
+	if len(trimmed) == len(text) || strings.HasSuffix(trimmed, `\`) {
+		return text
+	}
+
+	return trimmed + `\` + text[len(trimmed):]
+}
@@ -0,0 +1,324 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/htmlcontent"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Import converts CommonMark to content blocks. Inline formatting is kept as
+// inline HTML in data.text, see htmlcontent.SanitizeInline() for the elements
+// that are kept. Thematic breaks and HTML blocks are left out.
+func Import(source []byte, cfg Config) ([]newsdoc.Block, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := goldmark.DefaultParser().Parse(text.NewReader(source))
+
+	imp := importer{cfg: cfg, source: source}
+
+	return imp.blocks(doc), nil
+}
+
+type importer struct {
+	cfg    Config
+	source []byte
+}
+
+func (imp *importer) blocks(parent ast.Node) []newsdoc.Block {
+	var res []newsdoc.Block
+
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		res = append(res, imp.block(n)...)
+	}
+
+	return res
+}
+
+func (imp *importer) block(n ast.Node) []newsdoc.Block {
+	switch node := n.(type) {
+	case *ast.Heading:
+		b := imp.cfg.Headings[min(node.Level, 6)-1].Block()
+
+		b.Data = newsdoc.DataMap{"text": imp.inline(node)}
+
+		return []newsdoc.Block{b}
+	case *ast.Paragraph, *ast.TextBlock:
+		if images := imp.images(node); images != nil {
+			return images
+		}
+
+		b := imp.cfg.Paragraph.Block()
+
+		b.Data = newsdoc.DataMap{"text": imp.inline(node)}
+
+		return []newsdoc.Block{b}
+	case *ast.List:
+		bt := imp.cfg.UnorderedList
+		if node.IsOrdered() {
+			bt = imp.cfg.OrderedList
+		}
+
+		b := bt.Block()
+
+		if node.IsOrdered() && node.Start != 1 {
+			b.Data = newsdoc.DataMap{"start": strconv.Itoa(node.Start)}
+		}
+
+		for item := node.FirstChild(); item != nil; item = item.NextSibling() {
+			b.Content = append(b.Content, imp.listItem(item))
+		}
+
+		return []newsdoc.Block{b}
+	case *ast.Blockquote:
+		b := imp.cfg.BlockQuote.Block()
+
+		b.Content = imp.blocks(node)
+
+		return []newsdoc.Block{b}
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		b := imp.cfg.CodeBlock.Block()
+
+		b.Data = newsdoc.DataMap{"text": html.EscapeString(imp.lines(n))}
+
+		if fenced, ok := node.(*ast.FencedCodeBlock); ok {
+			lang := string(fenced.Language(imp.source))
+			if lang != "" {
+				b.Data["language"] = lang
+			}
+		}
+
+		return []newsdoc.Block{b}
+	}
+
+	return nil
+}
+
+// listItem converts a list item, the text of the first paragraph becomes the
+// text of the item and any other blocks become its content.
+func (imp *importer) listItem(n ast.Node) newsdoc.Block {
+	b := imp.cfg.ListItem.Block()
+
+	b.Data = newsdoc.DataMap{"text": ""}
+
+	child := n.FirstChild()
+
+	switch child.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+		b.Data["text"] = imp.inline(child)
+		child = child.NextSibling()
+	}
+
+	for ; child != nil; child = child.NextSibling() {
+		b.Content = append(b.Content, imp.block(child)...)
+	}
+
+	return b
+}
+
+// images returns image blocks for a paragraph that only contains images, or
+// nil if the paragraph has other content.
+func (imp *importer) images(n ast.Node) []newsdoc.Block {
+	var res []newsdoc.Block
+
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch node := c.(type) {
+		case *ast.Image:
+			b := imp.cfg.Image.Block()
+
+			b.URL = unescape(node.Destination)
+			b.Data = newsdoc.DataMap{
+				"alt": imp.plain(node),
+			}
+
+			if len(node.Title) > 0 {
+				b.Data["text"] = unescape(node.Title)
+			}
+
+			res = append(res, b)
+		case *ast.Text:
+			if len(bytes.TrimSpace(node.Value(imp.source))) > 0 {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+
+	return res
+}
+
+func (imp *importer) lines(n ast.Node) string {
+	var buf bytes.Buffer
+
+	lines := n.Lines()
+
+	for i := range lines.Len() {
+		seg := lines.At(i)
+
+		buf.Write(seg.Value(imp.source))
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func (imp *importer) inline(n ast.Node) string {
+	var buf strings.Builder
+
+	imp.writeInline(&buf, n)
+
+	return htmlcontent.SanitizeInline(strings.TrimSpace(buf.String()))
+}
+
+func (imp *importer) writeInline(buf *strings.Builder, parent ast.Node) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch node := n.(type) {
+		case *ast.Text:
+			buf.WriteString(html.EscapeString(unescape(node.Value(imp.source))))
+
+			switch {
+			case node.HardLineBreak():
+				buf.WriteString("<br>")
+			case node.SoftLineBreak():
+				buf.WriteString(" ")
+			}
+		case *ast.String:
+			buf.WriteString(html.EscapeString(string(node.Value)))
+		case *ast.Emphasis:
+			tag := "em"
+			if node.Level > 1 {
+				tag = "strong"
+			}
+
+			buf.WriteString("<" + tag + ">")
+			imp.writeInline(buf, node)
+			buf.WriteString("</" + tag + ">")
+		case *ast.CodeSpan:
+			buf.WriteString("<code>")
+			buf.WriteString(html.EscapeString(imp.code(node)))
+			buf.WriteString("</code>")
+		case *ast.Link:
+			buf.WriteString(`<a href="`)
+			buf.WriteString(html.EscapeString(unescape(node.Destination)))
+			buf.WriteString(`">`)
+			imp.writeInline(buf, node)
+			buf.WriteString("</a>")
+		case *ast.AutoLink:
+			href := string(node.URL(imp.source))
+			if node.AutoLinkType == ast.AutoLinkEmail {
+				href = "mailto:" + href
+			}
+
+			buf.WriteString(`<a href="`)
+			buf.WriteString(html.EscapeString(href))
+			buf.WriteString(`">`)
+			buf.WriteString(html.EscapeString(string(node.Label(imp.source))))
+			buf.WriteString("</a>")
+		case *ast.Image:
+			// Images in running text are replaced by their alt text.
+			buf.WriteString(html.EscapeString(imp.plain(node)))
+		case *ast.RawHTML:
+			for i := range node.Segments.Len() {
+				seg := node.Segments.At(i)
+
+				buf.Write(seg.Value(imp.source))
+			}
+		default:
+			imp.writeInline(buf, node)
+		}
+	}
+}
+
+// plain returns the text of the inline children of a node without any
+// formatting.
+func (imp *importer) plain(parent ast.Node) string {
+	var buf strings.Builder
+
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch node := n.(type) {
+		case *ast.Text:
+			buf.WriteString(unescape(node.Value(imp.source)))
+
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(node.Value)
+		default:
+			buf.WriteString(imp.plain(node))
+		}
+	}
+
+	return buf.String()
+}
+
+// code returns the raw text of a code span, where backslash escapes and
+// character references aren't resolved.
+func (imp *importer) code(parent ast.Node) string {
+	var buf strings.Builder
+
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		t, ok := n.(*ast.Text)
+		if !ok {
+			continue
+		}
+
+		buf.Write(t.Value(imp.source))
+	}
+
+	return buf.String()
+}
+
+// entityExp matches a character reference at the start of a string.
+var entityExp = regexp.MustCompile(
+	`^&(#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+
+// unescape resolves backslash escapes and character references. Escaped
+// ampersands don't start character references.
+func unescape(v []byte) string {
+	var sb strings.Builder
+
+	s := string(v)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			sb.WriteByte(s[i+1])
+
+			i++
+		case c == '&':
+			ref := entityExp.FindString(s[i:])
+			if ref == "" {
+				sb.WriteByte(c)
+
+				continue
+			}
+
+			sb.WriteString(html.UnescapeString(ref))
+
+			i += len(ref) - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
+
+// isPunct reports whether c is ASCII punctuation, which can be escaped with a
+// backslash.
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) != -1
+}
@@ -0,0 +1,88 @@
+// Package markdown converts between CommonMark and NewsDoc content blocks.
+package markdown
+
+import (
+	"fmt"
+
+	"github.com/ttab/newsdoc/htmlcontent"
+)
+
+// BlockType is the type and role of a block.
+type BlockType = htmlcontent.BlockType
+
+// Config maps Markdown constructs to content block types.
+//
+// Text is stored in data.text as inline HTML. Images store their URL in the
+// block URL, their alt text in data.alt, and their title in data.text. List
+// items and block quotes keep their nested blocks as content. Code blocks
+// store their escaped text in data.text and the info string in data.language.
+type Config struct {
+	Paragraph BlockType `json:"paragraph"`
+	// Headings are the block types for the heading levels 1-6.
+	Headings      [6]BlockType `json:"headings"`
+	UnorderedList BlockType    `json:"unorderedlist"`
+	OrderedList   BlockType    `json:"orderedlist"`
+	ListItem      BlockType    `json:"listitem"`
+	BlockQuote    BlockType    `json:"blockquote"`
+	Image         BlockType    `json:"image"`
+	CodeBlock     BlockType    `json:"codeblock"`
+}
+
+// DefaultConfig returns a configuration that uses core/text blocks with roles
+// for paragraphs, headings, list items and code, core/unordered-list and
+// core/ordered-list for lists, core/blockquote for block quotes, and
+// core/image for images.
+func DefaultConfig() Config {
+	return Config{
+		Paragraph: BlockType{Type: "core/text"},
+		Headings: [6]BlockType{
+			{Type: "core/text", Role: "heading-1"},
+			{Type: "core/text", Role: "heading-2"},
+			{Type: "core/text", Role: "heading-3"},
+			{Type: "core/text", Role: "heading-4"},
+			{Type: "core/text", Role: "heading-5"},
+			{Type: "core/text", Role: "heading-6"},
+		},
+		UnorderedList: BlockType{Type: "core/unordered-list"},
+		OrderedList:   BlockType{Type: "core/ordered-list"},
+		ListItem:      BlockType{Type: "core/text", Role: "list-item"},
+		BlockQuote:    BlockType{Type: "core/blockquote"},
+		Image:         BlockType{Type: "core/image"},
+		CodeBlock:     BlockType{Type: "core/text", Role: "preformatted"},
+	}
+}
+
+// Validate checks that all block types are set and that no two constructs
+// use the same block type.
+func (c Config) Validate() error {
+	names := []string{
+		"paragraph", "unordered list", "ordered list", "list item",
+		"block quote", "image", "code block",
+	}
+	types := []BlockType{
+		c.Paragraph, c.UnorderedList, c.OrderedList, c.ListItem,
+		c.BlockQuote, c.Image, c.CodeBlock,
+	}
+
+	for i, h := range c.Headings {
+		names = append(names, fmt.Sprintf("heading %d", i+1))
+		types = append(types, h)
+	}
+
+	seen := make(map[BlockType]string, len(types))
+
+	for i, bt := range types {
+		if bt.Type == "" {
+			return fmt.Errorf("no block type for %s", names[i])
+		}
+
+		if other, ok := seen[bt]; ok {
+			return fmt.Errorf(
+				"%s and %s use the same block type", other, names[i])
+		}
+
+		seen[bt] = names[i]
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package markdown_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+	"github.com/ttab/newsdoc/markdown"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, name := range []string{"article", "lists"} {
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(filepath.Join("testdata", name+".md"))
+			test.Mustf(t, err, "read source")
+
+			blocks, err := markdown.Import(source, markdown.DefaultConfig())
+			test.Mustf(t, err, "import markdown")
+
+			test.AgainstGolden(t, test.Regenerate(), blocks,
+				filepath.Join("testdata", "TestRoundTrip", name+".json"))
+
+			out, err := markdown.Export(blocks, markdown.DefaultConfig())
+			test.Mustf(t, err, "export markdown")
+
+			test.EqualDiffWithOptionsf(t, string(source), string(out), nil,
+				"export must match the source")
+		})
+	}
+}
+
+func TestImportNormalization(t *testing.T) {
+	source := `Setext heading
+==============
+
+* Starred item
++ Plus item
+
+A paragraph with __strong__ and _emphasis_,
+a soft break and a <span>span</span>.
+
+    indented code
+`
+
+	blocks, err := markdown.Import([]byte(source), markdown.DefaultConfig())
+	test.Mustf(t, err, "import markdown")
+
+	out, err := markdown.Export(blocks, markdown.DefaultConfig())
+	test.Mustf(t, err, "export markdown")
+
+	want := "# Setext heading\n\n" +
+		"- Starred item\n\n" +
+		"* Plus item\n\n" +
+		"A paragraph with **strong** and *emphasis*, " +
+		"a soft break and a span.\n\n" +
+		"```\nindented code\n```\n"
+
+	test.EqualDiffWithOptionsf(t, want, string(out), nil,
+		"must export normalized markdown")
+}
+
+func TestExportEscaping(t *testing.T) {
+	texts := []string{
+		`Stars *and* _underscores_ and [brackets] and \backslashes\`,
+		"# hash, 1. number and - dash",
+		"- dash",
+		"+ plus",
+		"> quote",
+		"12) parenthesis",
+		"Entities like &amp;amp; and &amp;#42; stay as text",
+		"Code <code>with `ticks` and *stars*</code>",
+		"<code>`</code>",
+		`A <a href="https://example.com/a_(b)">link (with parens)</a>`,
+		`<a href="https://example.com/a b">Space</a>`,
+		"Bold<strong> with spaces </strong>around",
+		"Line<br>&gt; break",
+		"Heading like<br>===",
+		"Trailing break<br>",
+	}
+
+	blocks := make([]newsdoc.Block, len(texts))
+
+	for i, text := range texts {
+		blocks[i] = newsdoc.Block{
+			Type: "core/text",
+			Data: newsdoc.DataMap{"text": text},
+		}
+	}
+
+	out, err := markdown.Export(blocks, markdown.DefaultConfig())
+	test.Mustf(t, err, "export markdown")
+
+	got, err := markdown.Import(out, markdown.DefaultConfig())
+	test.Mustf(t, err, "import exported markdown")
+
+	want := []string{
+		`Stars *and* _underscores_ and [brackets] and \backslashes\`,
+		"# hash, 1. number and - dash",
+		"- dash",
+		"+ plus",
+		"&gt; quote",
+		"12) parenthesis",
+		"Entities like &amp;amp; and &amp;#42; stay as text",
+		"Code <code>with `ticks` and *stars*</code>",
+		"<code>`</code>",
+		`A <a href="https://example.com/a_(b)">link (with parens)</a>`,
+		`<a href="https://example.com/a b">Space</a>`,
+		"Bold <strong>with spaces</strong> around",
+		"Line<br>&gt; break",
+		"Heading like<br>===",
+		"Trailing break",
+	}
+
+	var gotTexts []string
+
+	for _, b := range got {
+		gotTexts = append(gotTexts, b.Data.Get("text", ""))
+	}
+
+	test.EqualDiffWithOptionsf(t, want, gotTexts, nil,
+		"texts must survive a round trip, got markdown:\n%s", out)
+}
+
+func TestCustomConfig(t *testing.T) {
+	cfg := markdown.DefaultConfig()
+
+	cfg.Paragraph = markdown.BlockType{Type: "core/paragraph"}
+	cfg.Headings[0] = markdown.BlockType{Type: "core/heading", Role: "title"}
+	cfg.ListItem = markdown.BlockType{Type: "core/list-item"}
+
+	blocks, err := markdown.Import([]byte("# Title\n\nBody text.\n\n- Item\n"), cfg)
+	test.Mustf(t, err, "import markdown")
+
+	want := []newsdoc.Block{
+		{
+			Type: "core/heading",
+			Role: "title",
+			Data: newsdoc.DataMap{"text": "Title"},
+		},
+		{
+			Type: "core/paragraph",
+			Data: newsdoc.DataMap{"text": "Body text."},
+		},
+		{
+			Type: "core/unordered-list",
+			Content: []newsdoc.Block{
+				{
+					Type: "core/list-item",
+					Data: newsdoc.DataMap{"text": "Item"},
+				},
+			},
+		},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, blocks, nil,
+		"must use the configured block types")
+
+	// Blocks of unknown types only contribute their nested content.
+	blocks = append(blocks, newsdoc.Block{
+		Type: "example/embed",
+		Content: []newsdoc.Block{
+			{Type: "core/paragraph", Data: newsdoc.DataMap{"text": "Nested"}},
+		},
+	})
+
+	out, err := markdown.Export(blocks, cfg)
+	test.Mustf(t, err, "export markdown")
+
+	test.EqualDiffWithOptionsf(t,
+		"# Title\n\nBody text.\n\n- Item\n\nNested\n", string(out), nil,
+		"must export the configured block types")
+
+	cfg.BlockQuote = cfg.Paragraph
+
+	_, err = markdown.Import(nil, cfg)
+	if err == nil {
+		t.Fatal("expected an error for a duplicate block type")
+	}
+
+	t.Logf("got expected error: %v", err)
+}
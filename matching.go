@@ -1,11 +1,40 @@
 package newsdoc
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	_ BlockMatcher = &FilterNode{}
+	_ BlockMatcher = BlockSelector{}
+
+	_ DescribedMatcher = &FilterNode{}
+	_ DescribedMatcher = BlockSelector{}
+	_ DescribedMatcher = BlockRole("")
+	_ DescribedMatcher = allMatcher{}
+	_ DescribedMatcher = anyMatcher{}
+	_ DescribedMatcher = notMatcher{}
+	_ DescribedMatcher = hasMatcher{}
+	_ DescribedMatcher = unknownAttributeMatcher{}
+)
+
 // BlockMatcher checks if a block matches a condition.
 type BlockMatcher interface {
 	// Match returns true if the block matches the condition.
 	Match(block Block) bool
 }
 
+// DescribedMatcher is a BlockMatcher that can describe its condition. All the
+// built-in matchers implement this interface, see DescribeMatcher().
+type DescribedMatcher interface {
+	BlockMatcher
+	// Describe returns a description of the condition.
+	Describe() MatcherDescription
+}
+
 // BlockMatchFunc is a custom BlockMatcher function.
 type BlockMatchFunc func(block Block) bool
 
@@ -14,6 +43,22 @@ func (fn BlockMatchFunc) Match(block Block) bool {
 	return fn(block)
 }
 
+// ParseMatcher parses a filter expression using the same syntax as the
+// parenthesized filters of value extractor selectors, f.ex.
+// "type='core/text' (role='heading' or role='preamble')".
+func ParseMatcher(expr string) (BlockMatcher, error) {
+	if len(bytes.TrimSpace([]byte(expr))) == 0 {
+		return nil, errors.New("empty filter expression")
+	}
+
+	filter, err := parseAttributes([]byte(expr))
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression: %w", err)
+	}
+
+	return filter, nil
+}
+
 // BlockRole can be used to check that a block has a specific role.
 type BlockRole string
 
@@ -22,92 +67,524 @@ func (role BlockRole) Match(block Block) bool {
 	return block.Role == string(role)
 }
 
+// Describe implements DescribedMatcher.
+func (role BlockRole) Describe() MatcherDescription {
+	return MatcherDescription{
+		Op:    MatcherOpAttribute,
+		Attr:  string(blockAttrRole),
+		Value: string(role),
+	}
+}
+
 // BlockMatchesAll returns a block matcher that returns true if a block matches
 // all the conditions.
 func BlockMatchesAll(matchers ...BlockMatcher) BlockMatcher {
-	return BlockMatchFunc(func(block Block) bool {
-		for _, m := range matchers {
-			if !m.Match(block) {
-				return false
-			}
+	return allMatcher(matchers)
+}
+
+type allMatcher []BlockMatcher
+
+func (m allMatcher) Match(block Block) bool {
+	for _, c := range m {
+		if !c.Match(block) {
+			return false
 		}
+	}
+
+	return true
+}
 
-		return true
-	})
+func (m allMatcher) Describe() MatcherDescription {
+	return MatcherDescription{
+		Op:       MatcherOpAll,
+		Matchers: describeMatchers(m),
+	}
 }
 
 // BlockMatchesAny returns a block matcher that returns true if a block matches
 // any of the conditions.
 func BlockMatchesAny(matchers ...BlockMatcher) BlockMatcher {
-	return BlockMatchFunc(func(block Block) bool {
-		for _, m := range matchers {
-			if m.Match(block) {
-				return true
-			}
+	return anyMatcher(matchers)
+}
+
+type anyMatcher []BlockMatcher
+
+func (m anyMatcher) Match(block Block) bool {
+	for _, c := range m {
+		if c.Match(block) {
+			return true
 		}
+	}
 
-		return false
-	})
+	return false
+}
+
+func (m anyMatcher) Describe() MatcherDescription {
+	return MatcherDescription{
+		Op:       MatcherOpAny,
+		Matchers: describeMatchers(m),
+	}
 }
 
 // BlockDoesntMatch returns a block matcher that negates the selector.
 func BlockDoesntMatch(selector BlockMatcher) BlockMatcher {
-	return BlockMatchFunc(func(block Block) bool {
-		return !selector.Match(block)
-	})
+	return notMatcher{m: selector}
+}
+
+type notMatcher struct {
+	m BlockMatcher
+}
+
+func (m notMatcher) Match(block Block) bool {
+	return !m.m.Match(block)
+}
+
+func (m notMatcher) Describe() MatcherDescription {
+	return MatcherDescription{
+		Op:       MatcherOpNot,
+		Matchers: describeMatchers([]BlockMatcher{m.m}),
+	}
+}
+
+// BlocksWithAttribute returns a BlockMatcher that matches blocks where the
+// attribute has the given value. Valid attributes are the same as for
+// selector filters: "id", "uuid", "uri", "url", "title", "type", "rel",
+// "role", "name", "value", "contenttype", and "sensitivity". The matcher for an
+// unknown attribute never matches, use BlockAttributeMatcher() to get an
+// error instead.
+func BlocksWithAttribute(attribute string, value string) BlockMatcher {
+	m, err := BlockAttributeMatcher(attribute, value)
+	if err != nil {
+		return unknownAttributeMatcher{attribute: attribute, value: value}
+	}
+
+	return m
+}
+
+// BlockAttributeMatcher returns a BlockMatcher that matches blocks where the
+// attribute has the given value, see BlocksWithAttribute(). Returns an error
+// if the attribute is unknown.
+func BlockAttributeMatcher(attribute string, value string) (BlockMatcher, error) {
+	if !isBlockAttribute(attribute) {
+		return nil, fmt.Errorf("unknown block attribute %q", attribute)
+	}
+
+	return &FilterNode{
+		Attr:  attribute,
+		Value: value,
+	}, nil
+}
+
+type unknownAttributeMatcher struct {
+	attribute string
+	value     string
+}
+
+func (m unknownAttributeMatcher) Match(_ Block) bool {
+	return false
+}
+
+func (m unknownAttributeMatcher) Describe() MatcherDescription {
+	return MatcherDescription{
+		Op:    MatcherOpAttribute,
+		Attr:  m.attribute,
+		Value: m.value,
+	}
+}
+
+// BlocksWithID returns a BlockMatcher that matches blocks with the given ID.
+func BlocksWithID(id string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrID), id)
+}
+
+// BlocksWithUUID returns a BlockMatcher that matches blocks with the given
+// UUID.
+func BlocksWithUUID(uuid string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrUUID), uuid)
+}
+
+// BlocksWithURI returns a BlockMatcher that matches blocks with the given URI.
+func BlocksWithURI(uri string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrURI), uri)
+}
+
+// BlocksWithURL returns a BlockMatcher that matches blocks with the given URL.
+func BlocksWithURL(url string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrURL), url)
 }
 
 // BlocksWithType returns a BlockMatcher that matches blocks with the given
 // type.
 func BlocksWithType(blockType string) BlockMatcher {
-	return blockSelector{
-		bType: &blockType,
-	}
+	return BlocksWithAttribute(string(blockAttrType), blockType)
+}
+
+// BlocksWithTitle returns a BlockMatcher that matches blocks with the given
+// title.
+func BlocksWithTitle(title string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrTitle), title)
 }
 
 // BlocksWithRel returns a BlockMatcher that matches blocks with the given rel.
 func BlocksWithRel(rel string) BlockMatcher {
-	return blockSelector{
-		rel: &rel,
-	}
+	return BlocksWithAttribute(string(blockAttrRel), rel)
+}
+
+// BlocksWithRole returns a BlockMatcher that matches blocks with the given
+// role.
+func BlocksWithRole(role string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrRole), role)
+}
+
+// BlocksWithName returns a BlockMatcher that matches blocks with the given
+// name.
+func BlocksWithName(name string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrName), name)
+}
+
+// BlocksWithValue returns a BlockMatcher that matches blocks with the given
+// value.
+func BlocksWithValue(value string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrValue), value)
+}
+
+// BlocksWithContentType returns a BlockMatcher that matches blocks with the
+// given content type.
+func BlocksWithContentType(contentType string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrContentType), contentType)
+}
+
+// BlocksWithSensitivity returns a BlockMatcher that matches blocks with the
+// given sensitivity.
+func BlocksWithSensitivity(sensitivity string) BlockMatcher {
+	return BlocksWithAttribute(string(blockAttrSensitivity), sensitivity)
 }
 
 // BlocksWithTypeAndRel returns a BlockMatcher that matches blocks with the
 // given type and rel.
 func BlocksWithTypeAndRel(blockType string, rel string) BlockMatcher {
-	return blockSelector{
-		bType: &blockType,
-		rel:   &rel,
+	return &FilterNode{
+		Op: FilterOpAnd,
+		Children: []FilterNode{
+			{Attr: string(blockAttrType), Value: blockType},
+			{Attr: string(blockAttrRel), Value: rel},
+		},
 	}
 }
 
 // BlocksWithTypeAndRole returns a BlockMatcher that matches blocks with the
 // given type and role.
 func BlocksWithTypeAndRole(blockType string, role string) BlockMatcher {
-	return blockSelector{
-		bType: &blockType,
-		role:  &role,
+	return &FilterNode{
+		Op: FilterOpAnd,
+		Children: []FilterNode{
+			{Attr: string(blockAttrType), Value: blockType},
+			{Attr: string(blockAttrRole), Value: role},
+		},
 	}
 }
 
-type blockSelector struct {
-	bType *string
-	rel   *string
-	role  *string
+// BlockHasData returns a BlockMatcher that matches blocks that have the data
+// key, even if the value is empty.
+func BlockHasData(key string) BlockMatcher {
+	return &FilterNode{Data: &DataFilter{
+		Key:  key,
+		Mode: DataFilterExists,
+	}}
+}
+
+// BlockHasNonEmptyData returns a BlockMatcher that matches blocks that have a
+// non-empty value for the data key.
+func BlockHasNonEmptyData(key string) BlockMatcher {
+	return &FilterNode{Data: &DataFilter{
+		Key:  key,
+		Mode: DataFilterNonEmpty,
+	}}
+}
+
+// BlockDataEquals returns a BlockMatcher that matches blocks where the data
+// key has the given value. A missing key is treated as an empty value.
+func BlockDataEquals(key string, value string) BlockMatcher {
+	return &FilterNode{Data: &DataFilter{
+		Key:   key,
+		Value: value,
+		Mode:  DataFilterExact,
+	}}
 }
 
-func (sel blockSelector) Match(block Block) bool {
-	if sel.bType != nil && *sel.bType != block.Type {
-		return false
+// BlockHasLink returns a BlockMatcher that matches blocks that have a link
+// matching the condition. This mirrors the "#.links(...)" child selector of
+// value extractors.
+func BlockHasLink(matcher BlockMatcher) BlockMatcher {
+	return hasMatcher{kind: BlockKindLinks, m: matcher}
+}
+
+// BlockHasMeta returns a BlockMatcher that matches blocks that have a meta
+// block matching the condition.
+func BlockHasMeta(matcher BlockMatcher) BlockMatcher {
+	return hasMatcher{kind: BlockKindMeta, m: matcher}
+}
+
+// BlockHasContent returns a BlockMatcher that matches blocks that have a
+// content block matching the condition.
+func BlockHasContent(matcher BlockMatcher) BlockMatcher {
+	return hasMatcher{kind: BlockKindContent, m: matcher}
+}
+
+type hasMatcher struct {
+	kind BlockKind
+	m    BlockMatcher
+}
+
+func (m hasMatcher) Match(block Block) bool {
+	for _, c := range childBlocks(block, m.kind) {
+		if m.m.Match(c) {
+			return true
+		}
 	}
 
-	if sel.rel != nil && *sel.rel != block.Rel {
-		return false
+	return false
+}
+
+func (m hasMatcher) Describe() MatcherDescription {
+	return MatcherDescription{
+		Op:       MatcherOpHas,
+		Kind:     m.kind,
+		Matchers: describeMatchers([]BlockMatcher{m.m}),
 	}
+}
 
-	if sel.role != nil && *sel.role != block.Role {
-		return false
+// Describe implements DescribedMatcher.
+func (fn *FilterNode) Describe() MatcherDescription {
+	if fn == nil {
+		return MatcherDescription{Op: MatcherOpAll}
 	}
 
-	return true
+	switch fn.Op {
+	case FilterOpAnd, FilterOpOr:
+		op := MatcherOpAll
+		if fn.Op == FilterOpOr {
+			op = MatcherOpAny
+		}
+
+		d := MatcherDescription{Op: op}
+
+		for i := range fn.Children {
+			d.Matchers = append(d.Matchers, fn.Children[i].Describe())
+		}
+
+		return d
+	}
+
+	if fn.Data != nil {
+		df := *fn.Data
+
+		return MatcherDescription{
+			Op:   MatcherOpData,
+			Data: &df,
+		}
+	}
+
+	return MatcherDescription{
+		Op:    MatcherOpAttribute,
+		Attr:  fn.Attr,
+		Value: fn.Value,
+	}
+}
+
+// Describe implements DescribedMatcher.
+func (bs BlockSelector) Describe() MatcherDescription {
+	return bs.Filter.Describe()
+}
+
+// DescribeMatcher returns the description of a matcher. Matchers that don't
+// implement DescribedMatcher, like BlockMatchFunc, are described as custom
+// matchers.
+func DescribeMatcher(m BlockMatcher) MatcherDescription {
+	dm, ok := m.(DescribedMatcher)
+	if !ok {
+		return MatcherDescription{Op: MatcherOpCustom}
+	}
+
+	return dm.Describe()
+}
+
+func describeMatchers(matchers []BlockMatcher) []MatcherDescription {
+	d := make([]MatcherDescription, len(matchers))
+
+	for i, m := range matchers {
+		d[i] = DescribeMatcher(m)
+	}
+
+	return d
+}
+
+// MatcherOp is the operation of a matcher description.
+type MatcherOp string
+
+const (
+	// MatcherOpAttribute matches a block attribute against a value.
+	MatcherOpAttribute MatcherOp = "attribute"
+	// MatcherOpData matches a data filter.
+	MatcherOpData MatcherOp = "data"
+	// MatcherOpAll matches if all the matchers match.
+	MatcherOpAll MatcherOp = "all"
+	// MatcherOpAny matches if any of the matchers match.
+	MatcherOpAny MatcherOp = "any"
+	// MatcherOpNot matches if the matcher doesn't match.
+	MatcherOpNot MatcherOp = "not"
+	// MatcherOpHas matches if any of the child blocks of the given kind
+	// match the matcher.
+	MatcherOpHas MatcherOp = "has"
+	// MatcherOpCustom is a matcher that can't be described, like a
+	// BlockMatchFunc.
+	MatcherOpCustom MatcherOp = "custom"
+)
+
+// MatcherDescription is a serializable description of a BlockMatcher.
+type MatcherDescription struct {
+	Op       MatcherOp
+	Attr     string               `json:",omitempty"`
+	Value    string               `json:",omitempty"`
+	Data     *DataFilter          `json:",omitempty"`
+	Kind     BlockKind            `json:",omitempty"`
+	Matchers []MatcherDescription `json:",omitempty"`
+}
+
+// Matcher creates a block matcher from the description. Returns an error if
+// the description contains custom matchers.
+func (d MatcherDescription) Matcher() (BlockMatcher, error) {
+	children := make([]BlockMatcher, len(d.Matchers))
+
+	for i := range d.Matchers {
+		m, err := d.Matchers[i].Matcher()
+		if err != nil {
+			return nil, err
+		}
+
+		children[i] = m
+	}
+
+	single := func() (BlockMatcher, error) {
+		if len(children) != 1 {
+			return nil, fmt.Errorf(
+				"%q matchers must have exactly one child", d.Op)
+		}
+
+		return children[0], nil
+	}
+
+	switch d.Op {
+	case MatcherOpAttribute:
+		return BlockAttributeMatcher(d.Attr, d.Value)
+	case MatcherOpData:
+		if d.Data == nil {
+			return nil, errors.New("data matcher without a data filter")
+		}
+
+		df := *d.Data
+
+		return &FilterNode{Data: &df}, nil
+	case MatcherOpAll:
+		return BlockMatchesAll(children...), nil
+	case MatcherOpAny:
+		return BlockMatchesAny(children...), nil
+	case MatcherOpNot:
+		m, err := single()
+		if err != nil {
+			return nil, err
+		}
+
+		return BlockDoesntMatch(m), nil
+	case MatcherOpHas:
+		m, err := single()
+		if err != nil {
+			return nil, err
+		}
+
+		switch d.Kind {
+		case BlockKindMeta, BlockKindLinks, BlockKindContent:
+		default:
+			return nil, fmt.Errorf("unknown block kind: %s", d.Kind)
+		}
+
+		return hasMatcher{kind: d.Kind, m: m}, nil
+	case MatcherOpCustom:
+		return nil, errors.New("custom matchers can't be recreated")
+	}
+
+	return nil, fmt.Errorf("unknown matcher op %q", d.Op)
+}
+
+// String returns the description in an extended filter syntax, f.ex.
+// "type='core/assignment' has.links(rel='deliverable')". Descriptions that
+// only use known attributes, data, all and any are valid filter expressions
+// that can be parsed with ParseMatcher().
+func (d MatcherDescription) String() string {
+	var sb strings.Builder
+
+	d.writeTo(&sb)
+
+	return sb.String()
+}
+
+func (d MatcherDescription) writeTo(sb *strings.Builder) {
+	switch d.Op {
+	case MatcherOpAttribute:
+		fn := FilterNode{Attr: d.Attr, Value: d.Value}
+
+		sb.WriteString(fn.String())
+	case MatcherOpData:
+		if d.Data != nil {
+			sb.WriteString(d.Data.String())
+		}
+	case MatcherOpAll, MatcherOpAny:
+		if len(d.Matchers) == 0 {
+			sb.WriteString(string(d.Op))
+			sb.WriteString("()")
+
+			return
+		}
+
+		for i, c := range d.Matchers {
+			if i > 0 && d.Op == MatcherOpAny {
+				sb.WriteString(" or ")
+			} else if i > 0 {
+				sb.WriteString(" ")
+			}
+
+			group := len(c.Matchers) > 1 &&
+				(c.Op == MatcherOpAny || c.Op == MatcherOpAll)
+
+			if group {
+				sb.WriteString("(")
+			}
+
+			c.writeTo(sb)
+
+			if group {
+				sb.WriteString(")")
+			}
+		}
+	case MatcherOpNot:
+		sb.WriteString("not(")
+
+		for _, c := range d.Matchers {
+			c.writeTo(sb)
+		}
+
+		sb.WriteString(")")
+	case MatcherOpHas:
+		sb.WriteString("has.")
+		sb.WriteString(string(d.Kind))
+		sb.WriteString("(")
+
+		for _, c := range d.Matchers {
+			c.writeTo(sb)
+		}
+
+		sb.WriteString(")")
+	case MatcherOpCustom:
+		sb.WriteString("custom()")
+	}
 }
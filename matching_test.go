@@ -1,6 +1,7 @@
 package newsdoc_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/ttab/newsdoc"
@@ -193,3 +194,462 @@ func TestBlockMatcherComposition(t *testing.T) {
 		t.Error("should not match text without heading role")
 	}
 }
+
+func TestParseMatcher(t *testing.T) {
+	matcher, err := newsdoc.ParseMatcher(
+		"type='core/text' (role='heading' or role='preamble')")
+	if err != nil {
+		t.Fatalf("parse matcher: %v", err)
+	}
+
+	blocks := []newsdoc.Block{
+		{Type: coreText, Role: "heading", Value: "Title"},
+		{Type: coreText, Role: "body", Value: "Paragraph"},
+		{Type: coreText, Role: "preamble", Value: "Lead"},
+		{Type: "core/image", Role: "heading"},
+	}
+
+	matched := newsdoc.AllBlocks(blocks, matcher)
+	if len(matched) != 2 {
+		t.Fatalf("expected 2 matching blocks, got %d", len(matched))
+	}
+
+	if matched[0].Value != "Title" || matched[1].Value != "Lead" {
+		t.Errorf("unexpected matches: %v", matched)
+	}
+
+	remaining := newsdoc.DropBlocks(blocks, matcher)
+	if len(remaining) != 2 {
+		t.Errorf("expected 2 remaining blocks, got %d", len(remaining))
+	}
+}
+
+func TestParseMatcherData(t *testing.T) {
+	matcher, err := newsdoc.ParseMatcher("data.public='false' or data.internal??")
+	if err != nil {
+		t.Fatalf("parse matcher: %v", err)
+	}
+
+	if !matcher.Match(newsdoc.Block{Data: newsdoc.DataMap{"public": "false"}}) {
+		t.Error("should match block with public=false")
+	}
+
+	if !matcher.Match(newsdoc.Block{Data: newsdoc.DataMap{"internal": "yes"}}) {
+		t.Error("should match block with non-empty internal")
+	}
+
+	if matcher.Match(newsdoc.Block{Data: newsdoc.DataMap{"internal": ""}}) {
+		t.Error("should not match block with empty internal")
+	}
+}
+
+func TestParseMatcherErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"  ",
+		"type=core/text",
+		"foo='bar'",
+		"type='a' or",
+		"(type='a'",
+	} {
+		_, err := newsdoc.ParseMatcher(expr)
+		if err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestBlockSelectorIsMatcher(t *testing.T) {
+	ve, err := newsdoc.ValueExtractorFromString(
+		".meta(type='core/text' role='heading').data{text}")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	var matcher newsdoc.BlockMatcher = ve.Selectors[0]
+
+	block, ok := newsdoc.FirstBlock([]newsdoc.Block{
+		{Type: coreText, Role: "body"},
+		{Type: coreText, Role: "heading", Value: "found"},
+	}, matcher)
+	if !ok || block.Value != "found" {
+		t.Error("expected the selector to match the heading block")
+	}
+}
+
+func TestAttributeMatchers(t *testing.T) {
+	block := newsdoc.Block{
+		ID:          "id-1",
+		UUID:        "uuid-1",
+		URI:         "core://thing/1",
+		URL:         "https://example.com/1",
+		Type:        coreText,
+		Title:       "A title",
+		Rel:         "item",
+		Role:        "heading",
+		Name:        "a-name",
+		Value:       "a-value",
+		Contenttype: "text/plain",
+		Sensitivity: "internal",
+	}
+
+	matchers := map[string]func(v string) newsdoc.BlockMatcher{
+		block.ID:          newsdoc.BlocksWithID,
+		block.UUID:        newsdoc.BlocksWithUUID,
+		block.URI:         newsdoc.BlocksWithURI,
+		block.URL:         newsdoc.BlocksWithURL,
+		block.Type:        newsdoc.BlocksWithType,
+		block.Title:       newsdoc.BlocksWithTitle,
+		block.Rel:         newsdoc.BlocksWithRel,
+		block.Role:        newsdoc.BlocksWithRole,
+		block.Name:        newsdoc.BlocksWithName,
+		block.Value:       newsdoc.BlocksWithValue,
+		block.Contenttype: newsdoc.BlocksWithContentType,
+		block.Sensitivity: newsdoc.BlocksWithSensitivity,
+	}
+
+	for value, fn := range matchers {
+		if !fn(value).Match(block) {
+			t.Errorf("matcher for %q should match the block", value)
+		}
+
+		if fn(value + "-other").Match(block) {
+			t.Errorf("matcher for %q should not match other values", value)
+		}
+	}
+}
+
+func TestUnknownAttributeMatcher(t *testing.T) {
+	// An empty value matches the empty attributes of the block, so a typo
+	// would match every block if unknown attributes were treated as
+	// empty.
+	matcher := newsdoc.BlocksWithAttribute("rle", "")
+
+	if matcher.Match(newsdoc.Block{Type: coreText}) {
+		t.Error("matcher for an unknown attribute should never match")
+	}
+
+	_, err := newsdoc.BlockAttributeMatcher("rle", "")
+	if err == nil {
+		t.Error("expected an error for an unknown attribute")
+	}
+
+	desc := newsdoc.DescribeMatcher(matcher)
+
+	_, err = desc.Matcher()
+	if err == nil {
+		t.Error("expected an error when recreating the matcher")
+	}
+
+	m, err := newsdoc.BlockAttributeMatcher("rel", "item")
+	if err != nil {
+		t.Fatalf("create matcher for a known attribute: %v", err)
+	}
+
+	if !m.Match(newsdoc.Block{Rel: "item"}) {
+		t.Error("matcher for a known attribute should match")
+	}
+}
+
+func TestDataMatchers(t *testing.T) {
+	block := newsdoc.Block{
+		Data: newsdoc.DataMap{"public": "true", "empty": ""},
+	}
+
+	if !newsdoc.BlockHasData("empty").Match(block) {
+		t.Error("BlockHasData should match an empty value")
+	}
+
+	if newsdoc.BlockHasNonEmptyData("empty").Match(block) {
+		t.Error("BlockHasNonEmptyData should not match an empty value")
+	}
+
+	if !newsdoc.BlockDataEquals("public", "true").Match(block) {
+		t.Error("BlockDataEquals should match the value")
+	}
+
+	if newsdoc.BlockDataEquals("public", "false").Match(block) {
+		t.Error("BlockDataEquals should not match other values")
+	}
+
+	if newsdoc.BlockHasData("missing").Match(newsdoc.Block{}) {
+		t.Error("BlockHasData should not match nil data")
+	}
+}
+
+func TestStructuralMatchers(t *testing.T) {
+	assignment := newsdoc.Block{
+		Type: "core/assignment",
+		Meta: []newsdoc.Block{
+			{Type: "core/assignment-type", Value: "text"},
+		},
+		Links: []newsdoc.Block{
+			{Rel: "deliverable", UUID: "abc"},
+		},
+		Content: []newsdoc.Block{
+			{Type: coreText},
+		},
+	}
+
+	if !newsdoc.BlockHasLink(newsdoc.BlocksWithRel("deliverable")).Match(assignment) {
+		t.Error("BlockHasLink should match")
+	}
+
+	if newsdoc.BlockHasLink(newsdoc.BlocksWithRel("other")).Match(assignment) {
+		t.Error("BlockHasLink should not match other rels")
+	}
+
+	if !newsdoc.BlockHasMeta(newsdoc.BlocksWithValue("text")).Match(assignment) {
+		t.Error("BlockHasMeta should match")
+	}
+
+	if !newsdoc.BlockHasContent(newsdoc.BlocksWithType(coreText)).Match(assignment) {
+		t.Error("BlockHasContent should match")
+	}
+
+	if newsdoc.BlockHasContent(newsdoc.BlocksWithType(coreText)).Match(newsdoc.Block{}) {
+		t.Error("BlockHasContent should not match blocks without content")
+	}
+}
+
+func TestDescribeMatcher(t *testing.T) {
+	matcher := newsdoc.BlockMatchesAll(
+		newsdoc.BlocksWithType("core/assignment"),
+		newsdoc.BlockMatchesAny(
+			newsdoc.BlockDataEquals("public", "true"),
+			newsdoc.BlockRole("public"),
+		),
+		newsdoc.BlockDoesntMatch(newsdoc.BlocksWithSensitivity("internal")),
+		newsdoc.BlockHasLink(newsdoc.BlocksWithTypeAndRel("core/article", "deliverable")),
+	)
+
+	desc := newsdoc.DescribeMatcher(matcher)
+
+	want := "type='core/assignment' (data.public='true' or role='public') " +
+		"not(sensitivity='internal') " +
+		"has.links(type='core/article' rel='deliverable')"
+
+	if desc.String() != want {
+		t.Errorf("expected description %q, got %q", want, desc.String())
+	}
+
+	data, err := json.Marshal(desc)
+	if err != nil {
+		t.Fatalf("marshal description: %v", err)
+	}
+
+	var decoded newsdoc.MatcherDescription
+
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatalf("unmarshal description: %v", err)
+	}
+
+	recreated, err := decoded.Matcher()
+	if err != nil {
+		t.Fatalf("recreate matcher: %v", err)
+	}
+
+	blocks := []newsdoc.Block{
+		{
+			Type: "core/assignment",
+			Role: "public",
+			Links: []newsdoc.Block{
+				{Type: "core/article", Rel: "deliverable"},
+			},
+		},
+		{
+			Type:        "core/assignment",
+			Role:        "public",
+			Sensitivity: "internal",
+			Links: []newsdoc.Block{
+				{Type: "core/article", Rel: "deliverable"},
+			},
+		},
+		{
+			Type: "core/assignment",
+			Data: newsdoc.DataMap{"public": "true"},
+		},
+	}
+
+	for i, b := range blocks {
+		if matcher.Match(b) != recreated.Match(b) {
+			t.Errorf("block %d: recreated matcher doesn't behave like the original", i)
+		}
+	}
+
+	if !recreated.Match(blocks[0]) || recreated.Match(blocks[1]) || recreated.Match(blocks[2]) {
+		t.Error("unexpected match results")
+	}
+}
+
+func TestDescribeMatcherParseable(t *testing.T) {
+	matcher := newsdoc.BlockMatchesAny(
+		newsdoc.BlocksWithTypeAndRole(coreText, "heading"),
+		newsdoc.BlockHasNonEmptyData("text"),
+	)
+
+	str := newsdoc.DescribeMatcher(matcher).String()
+
+	parsed, err := newsdoc.ParseMatcher(str)
+	if err != nil {
+		t.Fatalf("parse description %q: %v", str, err)
+	}
+
+	if newsdoc.DescribeMatcher(parsed).String() != str {
+		t.Errorf("expected the parsed matcher to describe itself as %q", str)
+	}
+}
+
+func TestDescribeCustomMatcher(t *testing.T) {
+	custom := newsdoc.BlockMatchFunc(func(_ newsdoc.Block) bool {
+		return true
+	})
+
+	desc := newsdoc.DescribeMatcher(newsdoc.BlockDoesntMatch(custom))
+
+	if desc.String() != "not(custom())" {
+		t.Errorf("unexpected description %q", desc.String())
+	}
+
+	_, err := desc.Matcher()
+	if err == nil {
+		t.Error("expected an error when recreating a custom matcher")
+	}
+}
+
+func TestMatcherRoundTrip(t *testing.T) {
+	cases := map[string]struct {
+		Matcher   newsdoc.BlockMatcher
+		Parseable bool
+	}{
+		"id":           {newsdoc.BlocksWithID("a"), true},
+		"uuid":         {newsdoc.BlocksWithUUID("a"), true},
+		"uri":          {newsdoc.BlocksWithURI("core://a"), true},
+		"url":          {newsdoc.BlocksWithURL("https://a"), true},
+		"type":         {newsdoc.BlocksWithType(coreText), true},
+		"title":        {newsdoc.BlocksWithTitle("x"), true},
+		"rel":          {newsdoc.BlocksWithRel("author"), true},
+		"role":         {newsdoc.BlocksWithRole("heading"), true},
+		"block_role":   {newsdoc.BlockRole("heading"), true},
+		"name":         {newsdoc.BlocksWithName("a"), true},
+		"value":        {newsdoc.BlocksWithValue("a"), true},
+		"content_type": {newsdoc.BlocksWithContentType("image/jpeg"), true},
+		"sensitivity":  {newsdoc.BlocksWithSensitivity("internal"), true},
+		"type_and_rel": {newsdoc.BlocksWithTypeAndRel("core/person", "author"), true},
+		"type_and_role": {
+			newsdoc.BlocksWithTypeAndRole(coreText, "heading"), true,
+		},
+		"has_data":      {newsdoc.BlockHasData("text"), true},
+		"non_empty":     {newsdoc.BlockHasNonEmptyData("text"), true},
+		"data_equals":   {newsdoc.BlockDataEquals("public", "true"), true},
+		"all":           {newsdoc.BlockMatchesAll(newsdoc.BlocksWithType(coreText)), true},
+		"any":           {newsdoc.BlockMatchesAny(newsdoc.BlockRole("a"), newsdoc.BlockRole("b")), true},
+		"not":           {newsdoc.BlockDoesntMatch(newsdoc.BlockRole("a")), false},
+		"has_link":      {newsdoc.BlockHasLink(newsdoc.BlocksWithRel("author")), false},
+		"has_meta":      {newsdoc.BlockHasMeta(newsdoc.BlocksWithType("core/note")), false},
+		"has_content":   {newsdoc.BlockHasContent(newsdoc.BlocksWithType(coreText)), false},
+		"parsed_filter": {mustParseMatcher(t, "type='core/text' (role='a' or role='b')"), true},
+	}
+
+	blocks := []newsdoc.Block{
+		{},
+		{
+			ID: "a", UUID: "a", URI: "core://a", URL: "https://a",
+			Type: coreText, Title: "x", Rel: "author", Role: "heading",
+			Name: "a", Value: "a", Contenttype: "image/jpeg",
+			Sensitivity: "internal",
+			Data:        newsdoc.DataMap{"text": "t", "public": "true"},
+		},
+		{Type: "core/person", Rel: "author", Role: "a", Data: newsdoc.DataMap{"text": ""}},
+		{
+			Type:    coreText,
+			Role:    "b",
+			Links:   []newsdoc.Block{{Rel: "author"}},
+			Meta:    []newsdoc.Block{{Type: "core/note"}},
+			Content: []newsdoc.Block{{Type: coreText}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desc := newsdoc.DescribeMatcher(tc.Matcher)
+
+			data, err := json.Marshal(desc)
+			if err != nil {
+				t.Fatalf("marshal description: %v", err)
+			}
+
+			var decoded newsdoc.MatcherDescription
+
+			err = json.Unmarshal(data, &decoded)
+			if err != nil {
+				t.Fatalf("unmarshal description: %v", err)
+			}
+
+			recreated, err := decoded.Matcher()
+			if err != nil {
+				t.Fatalf("recreate matcher: %v", err)
+			}
+
+			checkSameMatches(t, blocks, tc.Matcher, recreated)
+
+			if !tc.Parseable {
+				return
+			}
+
+			parsed, err := newsdoc.ParseMatcher(desc.String())
+			if err != nil {
+				t.Fatalf("parse description %q: %v", desc.String(), err)
+			}
+
+			if got := newsdoc.DescribeMatcher(parsed).String(); got != desc.String() {
+				t.Errorf("expected the parsed matcher to describe itself as %q, got %q",
+					desc.String(), got)
+			}
+
+			checkSameMatches(t, blocks, tc.Matcher, parsed)
+		})
+	}
+}
+
+func TestUnknownAttributeMatcherRoundTrip(t *testing.T) {
+	desc := newsdoc.DescribeMatcher(newsdoc.BlocksWithAttribute("colour", "red"))
+
+	if desc.String() != "colour='red'" {
+		t.Errorf("unexpected description %q", desc.String())
+	}
+
+	_, err := desc.Matcher()
+	if err == nil {
+		t.Error("expected an error when recreating an unknown attribute matcher")
+	}
+
+	_, err = newsdoc.ParseMatcher(desc.String())
+	if err == nil {
+		t.Error("expected an error when parsing an unknown attribute")
+	}
+}
+
+func mustParseMatcher(t *testing.T, expr string) newsdoc.BlockMatcher {
+	t.Helper()
+
+	m, err := newsdoc.ParseMatcher(expr)
+	if err != nil {
+		t.Fatalf("parse matcher %q: %v", expr, err)
+	}
+
+	return m
+}
+
+func checkSameMatches(
+	t *testing.T, blocks []newsdoc.Block, want newsdoc.BlockMatcher, got newsdoc.BlockMatcher,
+) {
+	t.Helper()
+
+	for i, b := range blocks {
+		if want.Match(b) != got.Match(b) {
+			t.Errorf("block %d: expected match to be %v", i, want.Match(b))
+		}
+	}
+}
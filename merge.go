@@ -0,0 +1,227 @@
+package newsdoc
+
+import (
+	"maps"
+	"slices"
+)
+
+// KeyFunc returns the identity of a block when merging block lists. Blocks
+// without an identity should return false, they are never matched against
+// other blocks.
+type KeyFunc func(b Block) (string, bool)
+
+// BlockKeyUUIDRel identifies blocks by UUID and rel. Blocks without a UUID
+// have no identity.
+func BlockKeyUUIDRel(b Block) (string, bool) {
+	if b.UUID == "" {
+		return "", false
+	}
+
+	return b.UUID + "\x00" + b.Rel, true
+}
+
+// BlockKeyURIRel identifies blocks by URI and rel. Blocks without a URI have
+// no identity.
+func BlockKeyURIRel(b Block) (string, bool) {
+	if b.URI == "" {
+		return "", false
+	}
+
+	return b.URI + "\x00" + b.Rel, true
+}
+
+// BlockKeyTypeRel identifies blocks by type and rel. Blocks without a type
+// have no identity.
+func BlockKeyTypeRel(b Block) (string, bool) {
+	if b.Type == "" {
+		return "", false
+	}
+
+	return b.Type + "\x00" + b.Rel, true
+}
+
+// MergeStrategy controls how an incoming block is merged with an existing
+// block that has the same identity.
+type MergeStrategy int
+
+const (
+	// MergeKeepExisting leaves the existing block unchanged.
+	MergeKeepExisting MergeStrategy = iota
+	// MergeReplace replaces the existing block with the incoming block.
+	MergeReplace
+	// MergeUpsertData uses the incoming block, but keeps data keys that
+	// only are set on the existing block, see UpsertData().
+	MergeUpsertData
+	// MergeDataDefaults keeps the existing block, but adds data values
+	// from the incoming block that are unset or empty on the existing
+	// block, see DataWithDefaults().
+	MergeDataDefaults
+)
+
+// MergeOption is an option for MergeBlocks().
+type MergeOption func(opts *mergeOptions)
+
+type mergeOptions struct {
+	removeMissing bool
+	removeScope   BlockMatcher
+}
+
+// MergeRemoveMissing removes existing blocks that have an identity that isn't
+// present among the incoming blocks. If scope is non-nil only existing blocks
+// matching the scope are removed, which is useful when the incoming blocks
+// only cover a subset of the list, like the links that are synced from an
+// external system.
+func MergeRemoveMissing(scope BlockMatcher) MergeOption {
+	return func(opts *mergeOptions) {
+		opts.removeMissing = true
+		opts.removeScope = scope
+	}
+}
+
+// MergeReport describes the changes made by MergeBlocks().
+type MergeReport struct {
+	Added   []Block
+	Updated []MergeUpdate
+	Removed []Block
+}
+
+// MergeUpdate is an existing block that was changed by a merge.
+type MergeUpdate struct {
+	Before Block
+	After  Block
+}
+
+// Changed returns true if the merge added, updated, or removed any blocks.
+func (r MergeReport) Changed() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Removed) > 0
+}
+
+// MergeBlocks merges the incoming blocks into the existing blocks. Blocks are
+// matched by the identity returned by key, and matched blocks are merged
+// according to the strategy. If several blocks share an identity they are
+// matched in order. Incoming blocks that don't match an existing block are
+// appended to the list.
+//
+// The existing list is left unchanged, and a new list is returned together
+// with a report of the changes.
+func MergeBlocks(
+	existing []Block, incoming []Block, key KeyFunc,
+	strategy MergeStrategy, options ...MergeOption,
+) ([]Block, MergeReport) {
+	var (
+		opts   mergeOptions
+		report MergeReport
+	)
+
+	for _, o := range options {
+		o(&opts)
+	}
+
+	result := slices.Clone(existing)
+	candidates := make(map[string][]int)
+
+	for i, b := range existing {
+		k, ok := key(b)
+		if !ok {
+			continue
+		}
+
+		candidates[k] = append(candidates[k], i)
+	}
+
+	matched := make([]bool, len(existing))
+
+	for _, in := range incoming {
+		k, ok := key(in)
+
+		if !ok || len(candidates[k]) == 0 {
+			result = append(result, in)
+			report.Added = append(report.Added, in)
+
+			continue
+		}
+
+		idx := candidates[k][0]
+		candidates[k] = candidates[k][1:]
+		matched[idx] = true
+
+		merged := mergeBlock(existing[idx], in, strategy)
+		if blocksEqual(existing[idx], merged) {
+			continue
+		}
+
+		result[idx] = merged
+
+		report.Updated = append(report.Updated, MergeUpdate{
+			Before: existing[idx],
+			After:  merged,
+		})
+	}
+
+	if !opts.removeMissing {
+		return result, report
+	}
+
+	keep := result[:0]
+
+	for i, b := range result {
+		if i < len(existing) && !matched[i] && removable(b, key, opts) {
+			report.Removed = append(report.Removed, b)
+
+			continue
+		}
+
+		keep = append(keep, b)
+	}
+
+	return keep, report
+}
+
+func removable(b Block, key KeyFunc, opts mergeOptions) bool {
+	_, ok := key(b)
+	if !ok {
+		return false
+	}
+
+	return opts.removeScope == nil || opts.removeScope.Match(b)
+}
+
+func mergeBlock(existing Block, incoming Block, strategy MergeStrategy) Block {
+	switch strategy {
+	case MergeKeepExisting:
+		return existing
+	case MergeReplace:
+		return incoming
+	case MergeUpsertData:
+		merged := incoming
+
+		merged.Data = UpsertData(maps.Clone(existing.Data), incoming.Data)
+
+		return merged
+	case MergeDataDefaults:
+		merged := existing
+
+		merged.Data = DataWithDefaults(maps.Clone(existing.Data), incoming.Data)
+
+		return merged
+	}
+
+	return existing
+}
+
+// blocksEqual compares two blocks, treating nil and empty data and block
+// lists as equal.
+func blocksEqual(a Block, b Block) bool {
+	if a.ID != b.ID || a.UUID != b.UUID || a.URI != b.URI ||
+		a.URL != b.URL || a.Type != b.Type || a.Title != b.Title ||
+		a.Rel != b.Rel || a.Role != b.Role || a.Name != b.Name ||
+		a.Value != b.Value || a.Contenttype != b.Contenttype ||
+		a.Sensitivity != b.Sensitivity {
+		return false
+	}
+
+	return maps.Equal(a.Data, b.Data) &&
+		slices.EqualFunc(a.Meta, b.Meta, blocksEqual) &&
+		slices.EqualFunc(a.Links, b.Links, blocksEqual) &&
+		slices.EqualFunc(a.Content, b.Content, blocksEqual)
+}
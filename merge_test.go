@@ -0,0 +1,155 @@
+package newsdoc_test
+
+import (
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+const (
+	uuidA = "6fd7e2a0-3d4b-4a33-9f0e-7a3c1a0f2b11"
+	uuidB = "0b9f8a52-8c1e-4d7a-b2f1-58d0e6c4a9e2"
+	uuidC = "f1c2d3e4-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+)
+
+func existingLinks() []newsdoc.Block {
+	return []newsdoc.Block{
+		{
+			UUID: uuidA, Type: "core/section", Rel: "section",
+			Title: "Sports",
+			Data:  newsdoc.DataMap{"local": "yes"},
+		},
+		{UUID: uuidB, Type: "core/story", Rel: "subject", Title: "Story"},
+		{Type: "core/note", Rel: "note", Title: "Local note"},
+	}
+}
+
+func incomingLinks() []newsdoc.Block {
+	return []newsdoc.Block{
+		{
+			UUID: uuidA, Type: "core/section", Rel: "section",
+			Title: "Sport",
+			Data:  newsdoc.DataMap{"code": "SPO", "local": ""},
+		},
+		{UUID: uuidC, Type: "core/story", Rel: "subject", Title: "Other"},
+	}
+}
+
+func TestMergeBlocksStrategies(t *testing.T) {
+	cases := map[newsdoc.MergeStrategy]newsdoc.Block{
+		newsdoc.MergeKeepExisting: existingLinks()[0],
+		newsdoc.MergeReplace:      incomingLinks()[0],
+		newsdoc.MergeUpsertData: {
+			UUID: uuidA, Type: "core/section", Rel: "section",
+			Title: "Sport",
+			Data:  newsdoc.DataMap{"code": "SPO", "local": ""},
+		},
+		newsdoc.MergeDataDefaults: {
+			UUID: uuidA, Type: "core/section", Rel: "section",
+			Title: "Sports",
+			Data:  newsdoc.DataMap{"code": "SPO", "local": "yes"},
+		},
+	}
+
+	for strategy, want := range cases {
+		existing := existingLinks()
+
+		result, report := newsdoc.MergeBlocks(
+			existing, incomingLinks(), newsdoc.BlockKeyUUIDRel, strategy)
+
+		if len(result) != 4 {
+			t.Fatalf("strategy %d: expected 4 blocks, got %d",
+				strategy, len(result))
+		}
+
+		test.EqualDiffWithOptionsf(t, want, result[0], nil,
+			"strategy %d: merged block", strategy)
+
+		test.EqualDiffWithOptionsf(t, existingLinks(), existing, nil,
+			"strategy %d: existing list must be left unchanged", strategy)
+
+		if len(report.Added) != 1 || report.Added[0].UUID != uuidC {
+			t.Errorf("strategy %d: expected the other story to be added, got %v",
+				strategy, report.Added)
+		}
+
+		wantUpdates := 1
+		if strategy == newsdoc.MergeKeepExisting {
+			wantUpdates = 0
+		}
+
+		if len(report.Updated) != wantUpdates {
+			t.Errorf("strategy %d: expected %d updates, got %d",
+				strategy, wantUpdates, len(report.Updated))
+		}
+	}
+}
+
+func TestMergeBlocksUnchanged(t *testing.T) {
+	result, report := newsdoc.MergeBlocks(
+		existingLinks(), existingLinks()[:2],
+		newsdoc.BlockKeyUUIDRel, newsdoc.MergeReplace)
+
+	if report.Changed() {
+		t.Errorf("expected no changes, got %+v", report)
+	}
+
+	test.EqualDiffWithOptionsf(t, existingLinks(), result, nil, "merged blocks")
+}
+
+func TestMergeBlocksRemoveMissing(t *testing.T) {
+	result, report := newsdoc.MergeBlocks(
+		existingLinks(), incomingLinks(),
+		newsdoc.BlockKeyUUIDRel, newsdoc.MergeUpsertData,
+		newsdoc.MergeRemoveMissing(nil))
+
+	var got []string
+
+	for _, b := range result {
+		got = append(got, b.Title)
+	}
+
+	// The note has no UUID, and thereby no identity, so it's kept.
+	test.EqualDiffWithOptionsf(t,
+		[]string{"Sport", "Local note", "Other"}, got, nil,
+		"merged blocks")
+
+	if len(report.Removed) != 1 || report.Removed[0].UUID != uuidB {
+		t.Errorf("expected the story to be removed, got %v", report.Removed)
+	}
+}
+
+func TestMergeBlocksRemoveMissingScope(t *testing.T) {
+	_, report := newsdoc.MergeBlocks(
+		existingLinks(), nil,
+		newsdoc.BlockKeyTypeRel, newsdoc.MergeReplace,
+		newsdoc.MergeRemoveMissing(newsdoc.BlocksWithRel("subject")))
+
+	if len(report.Removed) != 1 || report.Removed[0].Rel != "subject" {
+		t.Errorf("expected only the subject to be removed, got %v",
+			report.Removed)
+	}
+}
+
+func TestMergeBlocksDuplicateKeys(t *testing.T) {
+	existing := []newsdoc.Block{
+		{URI: "tag://a", Rel: "subject", Title: "First"},
+		{URI: "tag://a", Rel: "subject", Title: "Second"},
+	}
+
+	incoming := []newsdoc.Block{
+		{URI: "tag://a", Rel: "subject", Title: "First"},
+		{URI: "tag://a", Rel: "subject", Title: "Second updated"},
+		{URI: "tag://a", Rel: "subject", Title: "Third"},
+	}
+
+	result, report := newsdoc.MergeBlocks(
+		existing, incoming, newsdoc.BlockKeyURIRel, newsdoc.MergeReplace)
+
+	test.EqualDiffWithOptionsf(t, incoming, result, nil, "merged blocks")
+
+	if len(report.Updated) != 1 || len(report.Added) != 1 {
+		t.Errorf("expected one update and one addition, got %+v", report)
+	}
+}
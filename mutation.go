@@ -0,0 +1,210 @@
+package newsdoc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Update calls fn for every block in the document that matches the selector
+// expression, f.ex. ".meta(type='core/assignment').links(rel='deliverable')".
+// The expression can use child selectors ('#') to only update blocks with
+// matching descendants. Returns the number of updated blocks.
+func Update(doc *Document, expr string, fn func(b *Block)) (int, error) {
+	selectors, childSelectors, err := parseSelectorExpression(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	n := mutateDocument(doc, selectors, childSelectors, func(b *Block) bool {
+		fn(b)
+
+		return true
+	})
+
+	return n, nil
+}
+
+// Delete removes every block in the document that matches the selector
+// expression. Returns the number of removed blocks.
+func Delete(doc *Document, expr string) (int, error) {
+	selectors, childSelectors, err := parseSelectorExpression(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	n := mutateDocument(doc, selectors, childSelectors, func(_ *Block) bool {
+		return false
+	})
+
+	return n, nil
+}
+
+// Set sets a block attribute or data value on every block that matches the
+// expression. The expression uses the same syntax as a value extractor with a
+// single value, f.ex. ".meta(type='core/newsvalue')@{value}" or
+// ".meta(type='core/planning-item').data{public}". If the expression has no
+// selectors the document attribute is set, f.ex. "@{title}". Returns the number
+// of updated blocks, or 1 for document attributes.
+func Set(doc *Document, expr string, value string) (int, error) {
+	ve, name, err := parseSetExpression(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ve.Selectors) == 0 {
+		if !setDocumentAttribute(doc, name, value) {
+			return 0, fmt.Errorf("unknown document attribute: %s", name)
+		}
+
+		return 1, nil
+	}
+
+	n := mutateDocument(doc, ve.Selectors, ve.ChildSelectors, func(b *Block) bool {
+		if ve.ValueKind == ValueKindAttributes {
+			setBlockAttribute(b, name, value)
+
+			return true
+		}
+
+		if b.Data == nil {
+			b.Data = make(DataMap)
+		}
+
+		b.Data[name] = value
+
+		return true
+	})
+
+	return n, nil
+}
+
+// parseSetExpression parses a set expression and returns the extractor
+// together with the name of the attribute or data key to set.
+func parseSetExpression(expr string) (*ValueExtractor, string, error) {
+	ve, err := ValueExtractorFromString(expr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if ve.ValueKind != ValueKindAttributes && ve.ValueKind != ValueKindData {
+		return nil, "", errors.New("set requires a single @{} or .data{} value")
+	}
+
+	if len(ve.Values) != 1 {
+		return nil, "", fmt.Errorf(
+			"set requires exactly one value, got %d", len(ve.Values))
+	}
+
+	name := ve.Values[0].Name
+
+	if len(ve.Selectors) > 0 && ve.ValueKind == ValueKindAttributes &&
+		!isBlockAttribute(name) {
+		return nil, "", fmt.Errorf("unknown attribute key: %s", name)
+	}
+
+	return ve, name, nil
+}
+
+// parseSelectorExpression parses a selector chain with optional child
+// selectors, f.ex. ".meta(type='core/assignment')#.links(rel='deliverable')".
+func parseSelectorExpression(expr string) ([]BlockSelector, []BlockSelector, error) {
+	text := []byte(expr)
+
+	var childText []byte
+
+	if hashIdx := indexByteOutsideQuotes(text, '#'); hashIdx != -1 {
+		childText = text[hashIdx+1:]
+		text = text[:hashIdx]
+	}
+
+	selectors, err := parseSelectors(text)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(selectors) == 0 {
+		return nil, nil, errors.New("at least one selector is required")
+	}
+
+	if childText == nil {
+		return selectors, nil, nil
+	}
+
+	childSelectors, err := parseSelectors(childText)
+	if err != nil {
+		return nil, nil, fmt.Errorf("child selectors: %w", err)
+	}
+
+	if len(childSelectors) == 0 {
+		return nil, nil, errors.New("empty child selector after '#'")
+	}
+
+	return selectors, childSelectors, nil
+}
+
+// mutateDocument calls fn for the blocks matching the selector chain, blocks
+// for which fn returns false are removed. Returns the number of matched blocks.
+func mutateDocument(
+	doc *Document, selectors []BlockSelector, childSelectors []BlockSelector,
+	fn func(b *Block) bool,
+) int {
+	kind := selectors[0].Kind
+
+	blocks, n := mutateBlocks(
+		documentBlocks(*doc, kind), selectors, childSelectors, fn)
+
+	setDocumentBlocks(doc, kind, blocks)
+
+	return n
+}
+
+func mutateBlocks(
+	blocks []Block, selectors []BlockSelector, childSelectors []BlockSelector,
+	fn func(b *Block) bool,
+) ([]Block, int) {
+	var count int
+
+	sel := selectors[0]
+
+	if len(selectors) > 1 {
+		kind := selectors[1].Kind
+
+		for i := range blocks {
+			if !sel.Matches(blocks[i]) {
+				continue
+			}
+
+			list, n := mutateBlocks(
+				childBlocks(blocks[i], kind),
+				selectors[1:], childSelectors, fn)
+
+			setChildBlocks(&blocks[i], kind, list)
+
+			count += n
+		}
+
+		return blocks, count
+	}
+
+	var keep int
+
+	for i := range blocks {
+		match := sel.Matches(blocks[i]) &&
+			hasMatchingChildren(blocks[i], childSelectors)
+
+		if match {
+			count++
+
+			if !fn(&blocks[i]) {
+				continue
+			}
+		}
+
+		blocks[keep] = blocks[i]
+		keep++
+	}
+
+	clear(blocks[keep:])
+
+	return blocks[:keep], count
+}
@@ -0,0 +1,166 @@
+package newsdoc_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func loadPlanningDocument(t *testing.T) newsdoc.Document {
+	t.Helper()
+
+	var doc newsdoc.Document
+
+	err := test.UnmarshalFile(
+		filepath.Join("testdata", "TestValueExtractor", "planning.json"),
+		&doc)
+	test.Mustf(t, err, "unmarshal document")
+
+	return doc
+}
+
+func TestUpdate(t *testing.T) {
+	doc := loadPlanningDocument(t)
+
+	n, err := newsdoc.Update(&doc,
+		".meta(type='core/assignment').links(rel='deliverable')",
+		func(b *newsdoc.Block) {
+			b.Title = "Deliverable"
+		})
+	test.Mustf(t, err, "update blocks")
+
+	if n != 1 {
+		t.Fatalf("expected 1 updated block, got %d", n)
+	}
+
+	if doc.Meta[5].Links[1].Title != "Deliverable" {
+		t.Errorf("deliverable link wasn't updated")
+	}
+
+	if doc.Meta[5].Links[0].Title != "A newswire" {
+		t.Errorf("non-matching link was updated")
+	}
+}
+
+func TestUpdateChildSelector(t *testing.T) {
+	doc := loadPlanningDocument(t)
+
+	n, err := newsdoc.Update(&doc,
+		".meta(type='core/assignment')#.meta(type='core/assignment-type' value='text')",
+		func(b *newsdoc.Block) {
+			b.Data["public"] = "false"
+		})
+	test.Mustf(t, err, "update blocks")
+
+	if n != 1 || doc.Meta[5].Data["public"] != "false" {
+		t.Errorf("expected the text assignment to be updated")
+	}
+
+	n, err = newsdoc.Update(&doc,
+		".meta(type='core/assignment')#.meta(type='core/assignment-type' value='picture')",
+		func(_ *newsdoc.Block) {
+			t.Error("no block should have been updated")
+		})
+	test.Mustf(t, err, "update blocks")
+
+	if n != 0 {
+		t.Errorf("expected no updated blocks, got %d", n)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	doc := loadPlanningDocument(t)
+
+	n, err := newsdoc.Delete(&doc, ".meta(type='core/assignment').links")
+	test.Mustf(t, err, "delete blocks")
+
+	if n != 2 {
+		t.Fatalf("expected 2 deleted blocks, got %d", n)
+	}
+
+	if len(doc.Meta[5].Links) != 0 {
+		t.Errorf("expected all assignment links to be removed")
+	}
+
+	n, err = newsdoc.Delete(&doc, ".meta(role='internal' or type='tt/slugline')")
+	test.Mustf(t, err, "delete blocks")
+
+	if n != 2 || len(doc.Meta) != 4 {
+		t.Fatalf("expected 2 deleted top level blocks, got %d", n)
+	}
+
+	for _, b := range doc.Meta {
+		if b.Type == "core/description" || b.Type == "tt/slugline" {
+			t.Errorf("block %q should have been deleted", b.Type)
+		}
+	}
+
+	if doc.Meta[3].Type != "core/assignment" {
+		t.Errorf("expected order of remaining blocks to be preserved")
+	}
+}
+
+func TestSet(t *testing.T) {
+	doc := loadPlanningDocument(t)
+
+	n, err := newsdoc.Set(&doc, ".meta(type='core/newsvalue')@{value}", "2")
+	test.Mustf(t, err, "set attribute")
+
+	if n != 1 || doc.Meta[4].Value != "2" {
+		t.Errorf("expected newsvalue to be set")
+	}
+
+	n, err = newsdoc.Set(&doc, ".links.data{checked}", "true")
+	test.Mustf(t, err, "set data")
+
+	if n != 2 {
+		t.Errorf("expected 2 updated links, got %d", n)
+	}
+
+	for _, l := range doc.Links {
+		if l.Data["checked"] != "true" {
+			t.Errorf("expected data to be set on %q", l.Rel)
+		}
+	}
+
+	n, err = newsdoc.Set(&doc, "@{title}", "New title")
+	test.Mustf(t, err, "set document attribute")
+
+	if n != 1 || doc.Title != "New title" {
+		t.Errorf("expected the document title to be set")
+	}
+}
+
+func TestMutationErrors(t *testing.T) {
+	doc := loadPlanningDocument(t)
+
+	_, err := newsdoc.Delete(&doc, "")
+	if err == nil {
+		t.Error("expected error for empty expression")
+	}
+
+	_, err = newsdoc.Delete(&doc, ".meta#")
+	if err == nil {
+		t.Error("expected error for empty child selector")
+	}
+
+	_, err = newsdoc.Update(&doc, ".widgets", func(_ *newsdoc.Block) {})
+	if err == nil {
+		t.Error("expected error for unknown block kind")
+	}
+
+	for _, exp := range []string{
+		".meta@{title value}",
+		".meta@{nonesuch}",
+		".meta@{title}.data{x}",
+		"b=.meta",
+		"@{nonesuch}",
+	} {
+		_, err = newsdoc.Set(&doc, exp, "x")
+		if err == nil {
+			t.Errorf("expected error for %q", exp)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package newsml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/htmlcontent"
+)
+
+// Import reads a NewsML-G2 newsItem as a document. If the root element is a
+// newsMessage the first news item in it is read.
+//
+// The guid becomes the document URI, and the document UUID is derived from
+// it, see newsdoc.UUIDFromURI(). The first headline becomes the title.
+// Elements in itemMeta, rightsInfo and contentMeta that aren't mapped to
+// other parts of the document are kept as data in meta blocks, with the
+// element name as key and the QCode, literal, URI or text as value.
+func Import(r io.Reader, cfg Config) (newsdoc.Document, Report, error) {
+	root, err := parseXML(r)
+	if err != nil {
+		return newsdoc.Document{}, Report{}, err
+	}
+
+	item := root.find("newsItem")
+	if item == nil {
+		return newsdoc.Document{}, Report{}, errors.New("no newsItem element")
+	}
+
+	imp := importer{
+		cfg:     cfg,
+		schemes: maps.Clone(cfg.Schemes),
+	}
+
+	if imp.schemes == nil {
+		imp.schemes = make(map[string]string)
+	}
+
+	doc, err := imp.item(item)
+	if err != nil {
+		return newsdoc.Document{}, Report{}, err
+	}
+
+	return doc, imp.report, nil
+}
+
+type importer struct {
+	cfg     Config
+	schemes map[string]string
+	report  Report
+}
+
+func (imp *importer) item(item *node) (newsdoc.Document, error) {
+	var doc newsdoc.Document
+
+	// Inline catalogs define scheme aliases that are used in the rest of
+	// the item.
+	for _, c := range item.elements() {
+		if c.Name.Local != "catalog" {
+			continue
+		}
+
+		for _, s := range c.elements() {
+			if s.Name.Local == "scheme" && s.attr("alias") != "" {
+				imp.schemes[s.attr("alias")] = s.attr("uri")
+			}
+		}
+	}
+
+	guid := item.attr("guid")
+	if guid != "" {
+		doc.URI = guid
+		doc.UUID = newsdoc.UUIDFromURI(guid)
+	}
+
+	doc.Language = item.attr("lang")
+
+	itemMeta := make(newsdoc.DataMap)
+
+	if v := item.attr("version"); v != "" {
+		itemMeta["version"] = v
+	}
+
+	contentMeta := make(newsdoc.DataMap)
+
+	for _, c := range item.elements() {
+		switch c.Name.Local {
+		case "catalog", "catalogRef":
+		case "itemMeta":
+			imp.itemMeta(&doc, c, itemMeta)
+		case "rightsInfo":
+			imp.metaData(c, "rightsInfo", itemMeta)
+		case "contentMeta":
+			imp.contentMeta(&doc, c, contentMeta)
+		case "contentSet":
+			content, err := imp.contentSet(c)
+			if err != nil {
+				return newsdoc.Document{}, err
+			}
+
+			doc.Content = content
+		default:
+			imp.report.Add(c.Name.Local, "unsupported element")
+		}
+	}
+
+	if len(itemMeta) > 0 {
+		doc.Meta = append(doc.Meta, newsdoc.Block{
+			Type: imp.cfg.ItemMetaType,
+			Data: itemMeta,
+		})
+	}
+
+	if len(contentMeta) > 0 {
+		doc.Meta = append(doc.Meta, newsdoc.Block{
+			Type: imp.cfg.ContentMetaType,
+			Data: contentMeta,
+		})
+	}
+
+	return doc, nil
+}
+
+func (imp *importer) itemMeta(doc *newsdoc.Document, n *node, data newsdoc.DataMap) {
+	class := n.child("itemClass")
+	if class != nil {
+		docType, ok := imp.cfg.Types[class.attr("qcode")]
+		if ok {
+			doc.Type = docType
+		}
+	}
+
+	for _, c := range n.elements() {
+		if c.Name.Local == "itemClass" && doc.Type != "" {
+			continue
+		}
+
+		imp.metaElement(c, "itemMeta", data)
+	}
+}
+
+func (imp *importer) contentMeta(doc *newsdoc.Document, n *node, data newsdoc.DataMap) {
+	for _, c := range n.elements() {
+		path := "contentMeta/" + c.Name.Local
+
+		switch c.Name.Local {
+		case "headline":
+			if doc.Title != "" {
+				imp.report.Add(path, "only the first headline is imported")
+
+				continue
+			}
+
+			doc.Title = c.text()
+		case "language":
+			if doc.Language == "" {
+				doc.Language = c.attr("tag")
+			}
+		case "subject":
+			doc.Links = append(doc.Links, imp.concept(c, imp.cfg.Subject, path))
+		case "genre":
+			doc.Links = append(doc.Links, imp.concept(c, imp.cfg.Genre, path))
+		case "creator", "contributor":
+			l := imp.concept(c, imp.cfg.Creator, path)
+
+			l.Role = c.attr("role")
+
+			delete(l.Data, "role")
+
+			if len(l.Data) == 0 {
+				l.Data = nil
+			}
+
+			doc.Links = append(doc.Links, l)
+		default:
+			imp.metaElement(c, "contentMeta", data)
+		}
+	}
+}
+
+// metaData adds the child elements of a node as meta data.
+func (imp *importer) metaData(n *node, path string, data newsdoc.DataMap) {
+	for _, c := range n.elements() {
+		imp.metaElement(c, path, data)
+	}
+}
+
+// metaElement adds an element as meta data. The value is the first of the
+// qcode, literal, uri and tag attributes that is set, or the text of the
+// element.
+func (imp *importer) metaElement(n *node, path string, data newsdoc.DataMap) {
+	key := n.Name.Local
+	path = path + "/" + key
+
+	var value string
+
+	for _, attr := range []string{"qcode", "literal", "uri", "tag"} {
+		value = n.attr(attr)
+		if value != "" {
+			break
+		}
+	}
+
+	if value == "" {
+		value = n.text()
+	}
+
+	if value == "" {
+		return
+	}
+
+	if _, exists := data[key]; exists {
+		imp.report.Add(path, "only the first %s element is imported", key)
+
+		return
+	}
+
+	data[key] = value
+}
+
+// concept converts a concept element like subject or genre to a link. The
+// QCode is resolved to a URI, unless the element has an explicit URI. Other
+// attributes are kept as data.
+func (imp *importer) concept(n *node, lt LinkType, path string) newsdoc.Block {
+	l := newsdoc.Block{
+		Type: lt.Type,
+		Rel:  lt.Rel,
+	}
+
+	qcode := n.attr("qcode")
+
+	l.Value = qcode
+	l.URI = n.attr("uri")
+
+	if l.URI == "" && qcode != "" {
+		uri, ok := resolveQCode(imp.schemes, qcode)
+		if ok {
+			l.URI = uri
+		} else {
+			imp.report.Add(path, "unknown scheme for the QCode %q", qcode)
+		}
+	}
+
+	for _, a := range n.Attr {
+		switch a.Name.Local {
+		case "qcode", "uri":
+		default:
+			if a.Name.Space != "" {
+				continue
+			}
+
+			if l.Data == nil {
+				l.Data = make(newsdoc.DataMap)
+			}
+
+			l.Data[a.Name.Local] = a.Value
+		}
+	}
+
+	for _, c := range n.elements() {
+		if c.Name.Local == "name" && l.Title == "" {
+			l.Title = c.text()
+
+			continue
+		}
+
+		imp.report.Add(path+"/"+c.Name.Local, "unsupported element")
+	}
+
+	// A literal is used for concepts that don't have a QCode, and works
+	// as a name if the concept doesn't have one.
+	if literal, ok := l.Data["literal"]; ok && l.Title == "" {
+		l.Title = literal
+
+		delete(l.Data, "literal")
+	}
+
+	if len(l.Data) == 0 {
+		l.Data = nil
+	}
+
+	return l
+}
+
+// contentSet imports the first inline XHTML, HTML or plain text content.
+func (imp *importer) contentSet(n *node) ([]newsdoc.Block, error) {
+	var (
+		selected *node
+		blocks   []newsdoc.Block
+		report   htmlcontent.ImportReport
+		err      error
+	)
+
+	for _, c := range n.elements() {
+		path := "contentSet/" + c.Name.Local
+
+		if selected != nil {
+			imp.report.Add(path, "only one content element is imported")
+
+			continue
+		}
+
+		switch c.Name.Local {
+		case "inlineXML":
+			var sb strings.Builder
+
+			body := c.find("body")
+			if body == nil {
+				body = c
+			}
+
+			writeHTML(&sb, body.Children)
+
+			selected = c
+			blocks, report, err = htmlcontent.Import(
+				strings.NewReader(sb.String()), imp.cfg.HTML)
+		case "inlineData":
+			if !strings.HasPrefix(c.attr("contenttype"), "text/plain") {
+				imp.report.Add(path, "unsupported content type %q", c.attr("contenttype"))
+
+				continue
+			}
+
+			selected = c
+			blocks, report, err = htmlcontent.ImportText(c.rawText(), imp.cfg.HTML)
+		default:
+			imp.report.Add(path, "unsupported element")
+
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("import %s: %w", path, err)
+		}
+
+		for _, d := range report.Dropped {
+			imp.report.Add(path, "dropped element %q: %s", d.Path, d.Reason)
+		}
+	}
+
+	return blocks, nil
+}
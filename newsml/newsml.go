@@ -0,0 +1,95 @@
+// Package newsml reads IPTC NewsML-G2 news items as NewsDoc documents.
+//
+// The item metadata and content metadata become meta blocks, subjects, genres
+// and creators become links with URIs resolved from their QCodes, and the
+// inline XHTML body becomes content blocks. Anything that can't be converted
+// is listed in a report.
+package newsml
+
+import (
+	"strings"
+
+	"github.com/ttab/newsdoc/htmlcontent"
+	"github.com/ttab/newsdoc/internal/convert"
+)
+
+// LinkType is the type and rel of a link.
+type LinkType = convert.LinkType
+
+// Config controls how news items are mapped to documents.
+type Config struct {
+	// Types maps item class QCodes, f.ex. "ninat:text", to document
+	// types.
+	Types map[string]string `json:"types"`
+	// Schemes maps QCode scheme aliases to scheme URIs, f.ex. "medtop" to
+	// "http://cv.iptc.org/newscodes/mediatopic/". Schemes from inline
+	// catalogs in the item take precedence.
+	Schemes map[string]string `json:"schemes"`
+	// ItemMetaType is the type of the meta block for itemMeta and
+	// rightsInfo.
+	ItemMetaType string `json:"itemmetatype"`
+	// ContentMetaType is the type of the meta block for contentMeta.
+	ContentMetaType string `json:"contentmetatype"`
+	// Subject links have the name as title, the QCode as value and the
+	// resolved QCode as URI.
+	Subject LinkType `json:"subject"`
+	// Genre links are mapped like subject links.
+	Genre LinkType `json:"genre"`
+	// Creator links are used for creators and contributors, the role
+	// QCode is used as the role of the link.
+	Creator LinkType `json:"creator"`
+	// HTML is used to import the XHTML body as content.
+	HTML htmlcontent.ImportConfig `json:"html"`
+}
+
+// DefaultConfig returns a configuration with the IPTC schemes for media
+// topics, subject codes, genres, news item natures, publishing statuses and
+// concept natures, and the default HTML import configuration.
+func DefaultConfig() Config {
+	return Config{
+		Types: map[string]string{
+			"ninat:text":      "core/article",
+			"ninat:picture":   "core/image",
+			"ninat:graphic":   "core/graphic",
+			"ninat:video":     "core/video",
+			"ninat:audio":     "core/audio",
+			"ninat:composite": "core/composite",
+		},
+		Schemes: map[string]string{
+			"medtop": "http://cv.iptc.org/newscodes/mediatopic/",
+			"subj":   "http://cv.iptc.org/newscodes/subjectcode/",
+			"genre":  "http://cv.iptc.org/newscodes/genre/",
+			"ninat":  "http://cv.iptc.org/newscodes/ninature/",
+			"stat":   "http://cv.iptc.org/newscodes/pubstatusg2/",
+			"cpnat":  "http://cv.iptc.org/newscodes/cpnature/",
+		},
+		ItemMetaType:    "newsml/item-meta",
+		ContentMetaType: "newsml/content-meta",
+		Subject:         LinkType{Type: "core/subject", Rel: "subject"},
+		Genre:           LinkType{Type: "core/genre", Rel: "genre"},
+		Creator:         LinkType{Type: "core/author", Rel: "author"},
+		HTML:            htmlcontent.DefaultImportConfig(),
+	}
+}
+
+// Lost is an element or attribute that couldn't be converted. The path is the
+// path of element names from the news item, f.ex. "contentMeta/subject".
+type Lost = convert.Lost
+
+// Report lists what was lost in a conversion.
+type Report = convert.Report
+
+// resolveQCode resolves a QCode to a URI using the scheme aliases.
+func resolveQCode(schemes map[string]string, qcode string) (string, bool) {
+	alias, code, ok := strings.Cut(qcode, ":")
+	if !ok {
+		return "", false
+	}
+
+	uri, ok := schemes[alias]
+	if !ok {
+		return "", false
+	}
+
+	return uri + code, true
+}
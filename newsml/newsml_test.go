@@ -0,0 +1,73 @@
+package newsml_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+	"github.com/ttab/newsdoc/newsml"
+)
+
+func TestImport(t *testing.T) {
+	for _, name := range []string{"newsitem", "newsmessage"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", name+".xml"))
+			test.Mustf(t, err, "open news item")
+
+			defer f.Close()
+
+			doc, report, err := newsml.Import(f, newsml.DefaultConfig())
+			test.Mustf(t, err, "import news item")
+
+			test.AgainstGolden(t, test.Regenerate(), doc,
+				filepath.Join("testdata", "TestImport", name+"-doc.json"))
+
+			test.AgainstGolden(t, test.Regenerate(), report,
+				filepath.Join("testdata", "TestImport", name+"-report.json"))
+		})
+	}
+}
+
+func TestImportEntities(t *testing.T) {
+	source := `<newsItem xmlns="http://iptc.org/std/nar/2006-10-01/" guid="urn:example:1">
+  <contentSet>
+    <inlineXML contenttype="application/xhtml+xml">
+      <html xmlns="http://www.w3.org/1999/xhtml">
+        <body><p>Before&nbsp;after &mdash; done &amp; dusted</p></body>
+      </html>
+    </inlineXML>
+  </contentSet>
+</newsItem>`
+
+	doc, _, err := newsml.Import(strings.NewReader(source), newsml.DefaultConfig())
+	test.Mustf(t, err, "import news item")
+
+	want := []newsdoc.Block{
+		{
+			Type: "core/text",
+			Data: newsdoc.DataMap{"text": "Before\u00a0after \u2014 done &amp; dusted"},
+		},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, doc.Content, nil,
+		"HTML entities must be decoded")
+}
+
+func TestImportInvalid(t *testing.T) {
+	sources := map[string]string{
+		"no news item": `<packageItem guid="urn:example:1"/>`,
+		"malformed":    `<newsItem guid="urn:example:1">`,
+	}
+
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := newsml.Import(strings.NewReader(source), newsml.DefaultConfig())
+			if err == nil {
+				t.Fatal("expected an import error")
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package newsml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+)
+
+// node is an XML element or text node, elements keep the order of their text
+// and element children.
+type node struct {
+	Name     xml.Name
+	Attr     []xml.Attr
+	Children []*node
+	// Text is the text of text nodes, which don't have a name.
+	Text string
+}
+
+func parseXML(r io.Reader) (*node, error) {
+	dec := xml.NewDecoder(r)
+
+	// XHTML content often uses HTML entities like &nbsp; that aren't
+	// defined in XML.
+	dec.Entity = xml.HTMLEntity
+
+	var (
+		root  *node
+		stack []*node
+	)
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("parse XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := node{Name: t.Name, Attr: t.Copy().Attr}
+
+			if len(stack) == 0 {
+				root = &n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, &n)
+			}
+
+			stack = append(stack, &n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, &node{Text: string(t)})
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+
+	return root, nil
+}
+
+func (n *node) isElement() bool {
+	return n.Name.Local != ""
+}
+
+// elements returns the child elements.
+func (n *node) elements() []*node {
+	var res []*node
+
+	for _, c := range n.Children {
+		if c.isElement() {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+// child returns the first child element with the local name.
+func (n *node) child(name string) *node {
+	for _, c := range n.Children {
+		if c.Name.Local == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// find returns the first descendant element with the local name, or the node
+// itself if it has the name.
+func (n *node) find(name string) *node {
+	if n.Name.Local == name {
+		return n
+	}
+
+	for _, c := range n.Children {
+		found := c.find(name)
+		if found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// attr returns the value of the attribute with the local name.
+func (n *node) attr(name string) string {
+	for _, a := range n.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// text returns the text of the node and its descendants with whitespace
+// collapsed.
+func (n *node) text() string {
+	var sb strings.Builder
+
+	n.writeText(&sb)
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// rawText returns the text of the node and its descendants as is.
+func (n *node) rawText() string {
+	var sb strings.Builder
+
+	n.writeText(&sb)
+
+	return sb.String()
+}
+
+func (n *node) writeText(sb *strings.Builder) {
+	if !n.isElement() {
+		sb.WriteString(n.Text)
+
+		return
+	}
+
+	for _, c := range n.Children {
+		c.writeText(sb)
+	}
+}
+
+// voidElements are HTML elements without end tags.
+var voidElements = []string{
+	"area", "base", "br", "col", "embed", "hr", "img", "input", "link",
+	"meta", "source", "track", "wbr",
+}
+
+// writeHTML serialises XHTML nodes as HTML.
+func writeHTML(sb *strings.Builder, nodes []*node) {
+	for _, n := range nodes {
+		if !n.isElement() {
+			sb.WriteString(html.EscapeString(n.Text))
+
+			continue
+		}
+
+		sb.WriteByte('<')
+		sb.WriteString(n.Name.Local)
+
+		for _, a := range n.Attr {
+			if a.Name.Space != "" || a.Name.Local == "xmlns" {
+				continue
+			}
+
+			sb.WriteByte(' ')
+			sb.WriteString(a.Name.Local)
+			sb.WriteString(`="`)
+			sb.WriteString(html.EscapeString(a.Value))
+			sb.WriteByte('"')
+		}
+
+		sb.WriteByte('>')
+
+		if slices.Contains(voidElements, n.Name.Local) {
+			continue
+		}
+
+		writeHTML(sb, n.Children)
+
+		sb.WriteString("</")
+		sb.WriteString(n.Name.Local)
+		sb.WriteByte('>')
+	}
+}
@@ -0,0 +1,447 @@
+package ninjs
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/htmlcontent"
+)
+
+// numericFields are ninjs fields that are numbers, their values are written
+// as numbers if they're valid numbers.
+var numericFields = []string{
+	"charcount", "confidence", "duration", "height", "priority",
+	"relevance", "sizeinbytes", "urgency", "width", "wordcount",
+}
+
+// itemFields are the ninjs fields that are written by the export, meta data
+// can't be used to set them.
+var itemFields = []string{
+	"$schema", "associations", "bodies", "body_html", "body_text",
+	"body_xhtml", "byline", "bylines", "headline", "headlines", "language",
+	"renditions", "standard", "subject", "subjects", "type", "uri",
+}
+
+// Export converts a document to a ninjs item of the given version.
+//
+// The document content is rendered as an HTML body. Links and meta blocks
+// that don't have a mapping in the configuration, and block fields that
+// ninjs has no place for, are listed in the report.
+func Export(doc newsdoc.Document, version Version, cfg Config) ([]byte, Report, error) {
+	if version != Version1 && version != Version2 {
+		return nil, Report{}, fmt.Errorf("unsupported ninjs version %d", version)
+	}
+
+	renderer := cfg.Renderer
+	if renderer == nil {
+		renderer = htmlcontent.NewRenderer()
+	}
+
+	exp := exporter{
+		cfg:      cfg,
+		version:  version,
+		renderer: renderer,
+	}
+
+	item, err := exp.item(doc)
+	if err != nil {
+		return nil, Report{}, err
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, Report{}, fmt.Errorf("marshal ninjs item: %w", err)
+	}
+
+	return data, exp.report, nil
+}
+
+type exporter struct {
+	cfg      Config
+	version  Version
+	renderer *htmlcontent.Renderer
+	report   Report
+}
+
+// field returns the field name for the version.
+func (exp *exporter) field(v1 string, v2 string) string {
+	if exp.version == Version1 {
+		return v1
+	}
+
+	return v2
+}
+
+func (exp *exporter) item(doc newsdoc.Document) (object, error) {
+	item := make(object)
+
+	if exp.version == Version2 {
+		item["standard"] = object{"name": "ninjs", "version": "2.1"}
+	}
+
+	switch {
+	case doc.URI != "":
+		item["uri"] = doc.URI
+
+		if doc.UUID != "" && doc.UUID != newsdoc.UUIDFromURI(doc.URI) {
+			exp.report.Add("uuid", "the UUID isn't derived from the URI")
+		}
+	case doc.UUID != "":
+		item["uri"] = "urn:uuid:" + doc.UUID
+	}
+
+	if doc.URL != "" {
+		exp.report.Add("url", "ninjs items have no URL")
+	}
+
+	if doc.Type != "" {
+		t, ok := exp.cfg.ninjsType(doc.Type)
+		if ok {
+			item["type"] = t
+		} else {
+			exp.report.Add("type", "no ninjs type for %q", doc.Type)
+		}
+	}
+
+	setString(item, "language", doc.Language)
+
+	exp.headline(item, doc.Title)
+
+	err := exp.body(item, doc.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, m := range doc.Meta {
+		path := indexField("meta", i)
+
+		if m.Type != exp.cfg.MetaType {
+			exp.report.Add(path, "no mapping for meta blocks of type %q", m.Type)
+
+			continue
+		}
+
+		exp.unused(path, m, "data")
+
+		for _, key := range slices.Sorted(maps.Keys(m.Data)) {
+			// A preserved type is only used when the document type
+			// couldn't be mapped.
+			if key == "type" && item["type"] == nil {
+				item["type"] = m.Data[key]
+
+				continue
+			}
+
+			if slices.Contains(itemFields, key) {
+				exp.report.Add(path+".data."+key,
+					"%q is set from the document", key)
+
+				continue
+			}
+
+			setValue(item, key, m.Data[key])
+		}
+	}
+
+	exp.links(item, doc.Links)
+
+	return item, nil
+}
+
+func (exp *exporter) headline(o object, title string) {
+	if title == "" {
+		return
+	}
+
+	if exp.version == Version1 {
+		o["headline"] = title
+
+		return
+	}
+
+	o["headlines"] = []object{{"value": title}}
+}
+
+func (exp *exporter) body(item object, content []newsdoc.Block) error {
+	if len(content) == 0 {
+		return nil
+	}
+
+	exp.unrendered("content", content)
+
+	var sb strings.Builder
+
+	err := exp.renderer.Render(&sb, content)
+	if err != nil {
+		return fmt.Errorf("render content: %w", err)
+	}
+
+	if exp.version == Version1 {
+		item["body_html"] = sb.String()
+
+		return nil
+	}
+
+	item["bodies"] = []object{{
+		"value":       sb.String(),
+		"contenttype": "text/html",
+	}}
+
+	return nil
+}
+
+// unrendered reports content blocks of types that don't have a render
+// function.
+func (exp *exporter) unrendered(path string, blocks []newsdoc.Block) {
+	for i, b := range blocks {
+		p := indexField(path, i)
+
+		if !exp.renderer.Registered(b.Type) {
+			exp.report.Add(p, "no render function for %q", b.Type)
+		}
+
+		exp.unrendered(p+".content", b.Content)
+	}
+}
+
+func (exp *exporter) links(item object, links []newsdoc.Block) {
+	var (
+		bylines      []string
+		bylineObjs   []object
+		subjects     []object
+		associations []namedObject
+		renditions   []namedObject
+	)
+
+	for i, l := range links {
+		path := indexField("links", i)
+
+		switch {
+		case matches(exp.cfg.Byline, l):
+			if exp.version == Version1 {
+				exp.unused(path, l, "title")
+
+				bylines = append(bylines, l.Title)
+
+				continue
+			}
+
+			exp.unused(path, l, "title", "role", "data")
+
+			o := dataObject(l.Data)
+
+			setString(o, "byline", l.Title)
+			setString(o, "role", l.Role)
+
+			bylineObjs = append(bylineObjs, o)
+		case matches(exp.cfg.Subject, l):
+			exp.unused(path, l, "title", "uri", "value", "data")
+
+			o := dataObject(l.Data)
+
+			setString(o, "name", l.Title)
+			setString(o, "uri", l.URI)
+			setString(o, "code", l.Value)
+
+			subjects = append(subjects, o)
+		case l.Rel == exp.cfg.Association.Rel:
+			associations = append(associations, exp.association(path, l))
+		case matches(exp.cfg.Rendition, l):
+			renditions = append(renditions, exp.rendition(path, l))
+		default:
+			exp.report.Add(path, "no mapping for links with rel %q and type %q",
+				l.Rel, l.Type)
+		}
+	}
+
+	if len(bylines) > 0 {
+		item["byline"] = strings.Join(bylines, ", ")
+	}
+
+	if len(bylineObjs) > 0 {
+		item["bylines"] = bylineObjs
+	}
+
+	if len(subjects) > 0 {
+		item[exp.field("subject", "subjects")] = subjects
+	}
+
+	exp.setNamed(item, "associations", "association", associations)
+	exp.setNamed(item, "renditions", "rendition", renditions)
+}
+
+func (exp *exporter) association(path string, l newsdoc.Block) namedObject {
+	used := []string{"title", "name", "data", "links", "uri"}
+
+	o := dataObject(l.Data)
+
+	switch {
+	case l.URI != "":
+		o["uri"] = l.URI
+	case l.UUID != "":
+		o["uri"] = "urn:uuid:" + l.UUID
+
+		used = append(used, "uuid")
+	}
+
+	if l.Type != exp.cfg.Association.Type {
+		t, ok := exp.cfg.ninjsType(l.Type)
+		if ok {
+			o["type"] = t
+		} else {
+			exp.report.Add(path+".type", "no ninjs type for %q", l.Type)
+		}
+	}
+
+	exp.unused(path, l, used...)
+	exp.headline(o, l.Title)
+
+	var renditions []namedObject
+
+	for i, r := range l.Links {
+		p := indexField(path+".links", i)
+
+		if !matches(exp.cfg.Rendition, r) {
+			exp.report.Add(p, "associations can only link to renditions")
+
+			continue
+		}
+
+		renditions = append(renditions, exp.rendition(p, r))
+	}
+
+	exp.setNamed(o, "renditions", "rendition", renditions)
+
+	return namedObject{Path: path, Name: l.Name, Object: o}
+}
+
+func (exp *exporter) rendition(path string, l newsdoc.Block) namedObject {
+	exp.unused(path, l, "title", "name", "url", "contenttype", "data")
+
+	o := dataObject(l.Data)
+
+	setString(o, "href", l.URL)
+	setString(o, exp.field("mimetype", "contenttype"), l.Contenttype)
+	setString(o, "title", l.Title)
+
+	return namedObject{Path: path, Name: l.Name, Object: o}
+}
+
+// setNamed sets a field that is an object with names as keys in ninjs 1.x,
+// and an array of objects with names in ninjs 2.x. Objects without a name
+// get a generated name in ninjs 1.x.
+func (exp *exporter) setNamed(o object, key string, prefix string, list []namedObject) {
+	if len(list) == 0 {
+		return
+	}
+
+	if exp.version == Version2 {
+		objects := make([]object, len(list))
+
+		for i, n := range list {
+			setString(n.Object, "name", n.Name)
+
+			objects[i] = n.Object
+		}
+
+		o[key] = objects
+
+		return
+	}
+
+	named := make(object, len(list))
+
+	for i, n := range list {
+		name := n.Name
+		if name == "" {
+			name = prefix + strconv.Itoa(i+1)
+		}
+
+		if _, exists := named[name]; exists {
+			exp.report.Add(n.Path, "the name %q is already used", name)
+
+			continue
+		}
+
+		named[name] = n.Object
+	}
+
+	o[key] = named
+}
+
+// unused reports the fields of a block that aren't used in the export.
+func (exp *exporter) unused(path string, b newsdoc.Block, used ...string) {
+	fields := []struct {
+		Name string
+		Set  bool
+	}{
+		{"id", b.ID != ""},
+		{"uuid", b.UUID != "" && (b.URI == "" || b.UUID != newsdoc.UUIDFromURI(b.URI))},
+		{"uri", b.URI != ""},
+		{"url", b.URL != ""},
+		{"title", b.Title != ""},
+		{"data", len(b.Data) > 0},
+		{"role", b.Role != ""},
+		{"name", b.Name != ""},
+		{"value", b.Value != ""},
+		{"contenttype", b.Contenttype != ""},
+		{"sensitivity", b.Sensitivity != ""},
+		{"links", len(b.Links) > 0},
+		{"content", len(b.Content) > 0},
+		{"meta", len(b.Meta) > 0},
+	}
+
+	for _, f := range fields {
+		if f.Set && !slices.Contains(used, f.Name) {
+			exp.report.Add(path+"."+f.Name, "no ninjs field for the block %s", f.Name)
+		}
+	}
+}
+
+func matches(lt LinkType, b newsdoc.Block) bool {
+	return b.Type == lt.Type && b.Rel == lt.Rel
+}
+
+func dataObject(data newsdoc.DataMap) object {
+	o := make(object, len(data))
+
+	for k, v := range data {
+		setValue(o, k, v)
+	}
+
+	return o
+}
+
+// setValue sets a field from a data value, numeric fields are set as
+// numbers.
+func setValue(o object, key string, value string) {
+	if slices.Contains(numericFields, key) && isNumber(value) {
+		o[key] = json.Number(value)
+
+		return
+	}
+
+	o[key] = value
+}
+
+// isNumber checks if the value is a JSON number literal.
+func isNumber(value string) bool {
+	if value == "" || (value[0] != '-' && (value[0] < '0' || value[0] > '9')) {
+		return false
+	}
+
+	return json.Valid([]byte(value))
+}
+
+func setString(o object, key string, value string) {
+	if value == "" {
+		return
+	}
+
+	o[key] = value
+}
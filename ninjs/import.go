@@ -0,0 +1,450 @@
+package ninjs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/htmlcontent"
+)
+
+// Import converts a ninjs 1.x or 2.x item to a document.
+//
+// The URI becomes the document URI, and the document UUID is derived from it,
+// see newsdoc.UUIDFromURI(). A "urn:uuid:" URI is used as the document UUID
+// instead. The first HTML body is imported using the HTML import
+// configuration, plain text bodies are imported as paragraphs. Scalar fields
+// without a mapping are kept as data in a meta block, other fields are listed
+// in the report.
+func Import(data []byte, cfg Config) (newsdoc.Document, Report, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	dec.UseNumber()
+
+	var item map[string]any
+
+	err := dec.Decode(&item)
+	if err != nil {
+		return newsdoc.Document{}, Report{}, fmt.Errorf("decode ninjs item: %w", err)
+	}
+
+	imp := importer{cfg: cfg}
+
+	doc, err := imp.item(object(item))
+	if err != nil {
+		return newsdoc.Document{}, Report{}, err
+	}
+
+	return doc, imp.report, nil
+}
+
+type importer struct {
+	cfg    Config
+	report Report
+}
+
+// namedObject is an object from a ninjs field that is either an array of
+// objects, or an object with names as keys.
+type namedObject struct {
+	Path   string
+	Name   string
+	Object object
+}
+
+func (imp *importer) item(o object) (newsdoc.Document, error) {
+	var doc newsdoc.Document
+
+	doc.URI, doc.UUID = documentIdentity(imp.takeString(o, "", "uri"))
+
+	t, _ := scalar(o["type"])
+	if docType, ok := imp.cfg.Types[t]; ok {
+		doc.Type = docType
+
+		delete(o, "type")
+	}
+
+	doc.Language = imp.takeString(o, "", "language")
+	doc.Title = imp.headline(o, "")
+
+	for _, v := range []string{"byline", "bylines"} {
+		doc.Links = append(doc.Links, imp.bylines(o, v)...)
+	}
+
+	content, err := imp.body(o)
+	if err != nil {
+		return newsdoc.Document{}, err
+	}
+
+	doc.Content = content
+
+	for _, v := range []string{"subject", "subjects"} {
+		doc.Links = append(doc.Links, imp.subjects(o, v)...)
+	}
+
+	for _, a := range imp.namedObjects(o, "associations", "") {
+		doc.Links = append(doc.Links, imp.association(a))
+	}
+
+	doc.Links = append(doc.Links, imp.renditions(o, "")...)
+
+	// The standard describes the format, and is set on export.
+	delete(o, "standard")
+	delete(o, "$schema")
+
+	data := imp.rest(o, "")
+	if len(data) > 0 {
+		doc.Meta = append(doc.Meta, newsdoc.Block{
+			Type: imp.cfg.MetaType,
+			Data: data,
+		})
+	}
+
+	return doc, nil
+}
+
+func documentIdentity(uri string) (string, string) {
+	if uri == "" {
+		return "", ""
+	}
+
+	id, ok := strings.CutPrefix(uri, "urn:uuid:")
+	if ok {
+		return "", id
+	}
+
+	return uri, newsdoc.UUIDFromURI(uri)
+}
+
+// headline reads the "headline" field, or the main headline of the
+// "headlines" field.
+func (imp *importer) headline(o object, path string) string {
+	title := imp.takeString(o, path, "headline")
+
+	headlines := imp.objects(o, "headlines", path)
+
+	main := -1
+	if title == "" {
+		main = mainIndex(headlines)
+	}
+
+	for i, h := range headlines {
+		if i == main {
+			title = imp.takeString(h.Object, h.Path, "value")
+
+			continue
+		}
+
+		imp.report.Add(h.Path, "only one headline is imported")
+	}
+
+	return title
+}
+
+// mainIndex returns the index of the object with the role "main", or the
+// first object.
+func mainIndex(list []namedObject) int {
+	for i, v := range list {
+		role, _ := scalar(v.Object["role"])
+		if role == "main" {
+			return i
+		}
+	}
+
+	if len(list) == 0 {
+		return -1
+	}
+
+	return 0
+}
+
+func (imp *importer) bylines(o object, key string) []newsdoc.Block {
+	if key == "byline" {
+		byline := imp.takeString(o, "", key)
+		if byline == "" {
+			return nil
+		}
+
+		return []newsdoc.Block{imp.link(imp.cfg.Byline, byline)}
+	}
+
+	var res []newsdoc.Block
+
+	for _, v := range imp.objects(o, key, "") {
+		b := imp.link(imp.cfg.Byline, imp.takeString(v.Object, v.Path, "byline"))
+
+		b.Role = imp.takeString(v.Object, v.Path, "role")
+		b.Data = imp.rest(v.Object, v.Path)
+
+		res = append(res, b)
+	}
+
+	return res
+}
+
+func (imp *importer) subjects(o object, key string) []newsdoc.Block {
+	var res []newsdoc.Block
+
+	for _, v := range imp.objects(o, key, "") {
+		b := imp.link(imp.cfg.Subject, imp.takeString(v.Object, v.Path, "name"))
+
+		b.URI = imp.takeString(v.Object, v.Path, "uri")
+		b.Value = imp.takeString(v.Object, v.Path, "code")
+		b.Data = imp.rest(v.Object, v.Path)
+
+		res = append(res, b)
+	}
+
+	return res
+}
+
+func (imp *importer) association(a namedObject) newsdoc.Block {
+	b := imp.link(imp.cfg.Association, "")
+
+	b.Name = a.Name
+	b.URI = imp.takeString(a.Object, a.Path, "uri")
+
+	t, _ := scalar(a.Object["type"])
+	if docType, ok := imp.cfg.Types[t]; ok {
+		b.Type = docType
+
+		delete(a.Object, "type")
+	}
+
+	b.Title = imp.headline(a.Object, a.Path)
+	b.Links = imp.renditions(a.Object, a.Path)
+	b.Data = imp.rest(a.Object, a.Path)
+
+	return b
+}
+
+func (imp *importer) renditions(o object, path string) []newsdoc.Block {
+	var res []newsdoc.Block
+
+	for _, r := range imp.namedObjects(o, "renditions", path) {
+		b := imp.link(imp.cfg.Rendition, imp.takeString(r.Object, r.Path, "title"))
+
+		b.Name = r.Name
+		b.URL = imp.takeString(r.Object, r.Path, "href")
+		// The mimetype was renamed to contenttype in ninjs 2.x.
+		b.Contenttype = imp.takeString(r.Object, r.Path, "mimetype")
+		if ct := imp.takeString(r.Object, r.Path, "contenttype"); ct != "" {
+			b.Contenttype = ct
+		}
+
+		b.Data = imp.rest(r.Object, r.Path)
+
+		res = append(res, b)
+	}
+
+	return res
+}
+
+func (imp *importer) link(lt LinkType, title string) newsdoc.Block {
+	return newsdoc.Block{
+		Type:  lt.Type,
+		Rel:   lt.Rel,
+		Title: title,
+	}
+}
+
+type body struct {
+	Path  string
+	Value string
+	HTML  bool
+}
+
+// body imports the first HTML body, or the first plain text body if there
+// are no HTML bodies.
+func (imp *importer) body(o object) ([]newsdoc.Block, error) {
+	var bodies []body
+
+	for _, key := range []string{"body_html", "body_xhtml"} {
+		v := imp.takeString(o, "", key)
+		if v != "" {
+			bodies = append(bodies, body{Path: key, Value: v, HTML: true})
+		}
+	}
+
+	if v := imp.takeString(o, "", "body_text"); v != "" {
+		bodies = append(bodies, body{Path: "body_text", Value: v})
+	}
+
+	for _, b := range imp.objects(o, "bodies", "") {
+		ct := imp.takeString(b.Object, b.Path, "contenttype")
+
+		bodies = append(bodies, body{
+			Path:  b.Path,
+			Value: imp.takeString(b.Object, b.Path, "value"),
+			HTML:  ct == "text/html" || ct == "application/xhtml+xml",
+		})
+	}
+
+	selected := -1
+
+	for i, b := range bodies {
+		if b.HTML {
+			selected = i
+
+			break
+		}
+	}
+
+	if selected == -1 && len(bodies) > 0 {
+		selected = 0
+	}
+
+	for i, b := range bodies {
+		if i != selected {
+			imp.report.Add(b.Path, "only one body is imported")
+		}
+	}
+
+	if selected == -1 {
+		return nil, nil
+	}
+
+	b := bodies[selected]
+
+	var (
+		blocks []newsdoc.Block
+		report htmlcontent.ImportReport
+		err    error
+	)
+
+	if b.HTML {
+		blocks, report, err = htmlcontent.Import(strings.NewReader(b.Value), imp.cfg.HTML)
+	} else {
+		blocks, report, err = htmlcontent.ImportText(b.Value, imp.cfg.HTML)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("import %s: %w", b.Path, err)
+	}
+
+	for _, d := range report.Dropped {
+		imp.report.Add(b.Path, "dropped element %q: %s", d.Path, d.Reason)
+	}
+
+	return blocks, nil
+}
+
+// takeString removes a field from the object and returns its value as a
+// string. Values that aren't scalars are reported as lost.
+func (imp *importer) takeString(o object, path string, key string) string {
+	v, ok := o.take(key)
+	if !ok {
+		return ""
+	}
+
+	s, ok := scalar(v)
+	if !ok {
+		imp.report.Add(joinField(path, key), "expected a string")
+	}
+
+	return s
+}
+
+// objects removes a field that holds an array of objects from the object.
+func (imp *importer) objects(o object, key string, path string) []namedObject {
+	v, ok := o.take(key)
+	if !ok {
+		return nil
+	}
+
+	p := joinField(path, key)
+
+	list, ok := v.([]any)
+	if !ok {
+		imp.report.Add(p, "expected an array")
+
+		return nil
+	}
+
+	var res []namedObject
+
+	for i, item := range list {
+		obj, ok := asObject(item)
+		if !ok {
+			imp.report.Add(indexField(p, i), "expected an object")
+
+			continue
+		}
+
+		res = append(res, namedObject{Path: indexField(p, i), Object: obj})
+	}
+
+	return res
+}
+
+// namedObjects removes a field that holds either an array of objects with
+// names, or an object with names as keys, from the object.
+func (imp *importer) namedObjects(o object, key string, path string) []namedObject {
+	v, ok := o.take(key)
+	if !ok {
+		return nil
+	}
+
+	p := joinField(path, key)
+
+	m, ok := asObject(v)
+	if !ok {
+		o[key] = v
+
+		res := imp.objects(o, key, path)
+
+		for i := range res {
+			res[i].Name = imp.takeString(res[i].Object, res[i].Path, "name")
+		}
+
+		return res
+	}
+
+	var res []namedObject
+
+	for _, name := range m.sortedKeys() {
+		obj, ok := asObject(m[name])
+		if !ok {
+			imp.report.Add(joinField(p, name), "expected an object")
+
+			continue
+		}
+
+		res = append(res, namedObject{
+			Path:   joinField(p, name),
+			Name:   name,
+			Object: obj,
+		})
+	}
+
+	return res
+}
+
+// rest returns the remaining scalar fields of an object as data, other fields
+// are reported as lost.
+func (imp *importer) rest(o object, path string) newsdoc.DataMap {
+	var data newsdoc.DataMap
+
+	for _, key := range o.sortedKeys() {
+		v := o[key]
+		if v == nil {
+			continue
+		}
+
+		s, ok := scalar(v)
+		if !ok {
+			imp.report.Add(joinField(path, key), "unsupported field")
+
+			continue
+		}
+
+		if data == nil {
+			data = make(newsdoc.DataMap)
+		}
+
+		data[key] = s
+	}
+
+	return data
+}
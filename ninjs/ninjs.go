@@ -0,0 +1,154 @@
+// Package ninjs converts between NewsDoc documents and IPTC ninjs, the JSON
+// news format. Both ninjs 1.x and 2.x are supported.
+//
+// The headline becomes the document title, bodies are converted to and from
+// content blocks as HTML, and bylines, subjects, associations and renditions
+// become links. Other scalar fields, f.ex. urgency and pubstatus, are kept as
+// data in a meta block. Anything that can't be converted is listed in a
+// report.
+package ninjs
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/ttab/newsdoc/htmlcontent"
+	"github.com/ttab/newsdoc/internal/convert"
+)
+
+// Version is a ninjs major version.
+type Version int
+
+const (
+	// Version1 is ninjs 1.x, with singular fields like "headline" and
+	// "subject", and associations and renditions as objects.
+	Version1 Version = 1
+	// Version2 is ninjs 2.x, with fields like "headlines" and "subjects",
+	// and associations and renditions as arrays.
+	Version2 Version = 2
+)
+
+// LinkType is the type and rel of a link.
+type LinkType = convert.LinkType
+
+// Config controls how ninjs is mapped to documents.
+type Config struct {
+	// Types maps ninjs item types, f.ex. "text" and "picture", to
+	// document types. It's used for both documents and associations.
+	Types map[string]string `json:"types"`
+	// MetaType is the type of the meta block that keeps scalar fields
+	// that don't have a mapping of their own, f.ex. urgency, pubstatus
+	// and versioncreated.
+	MetaType string `json:"metatype"`
+	// Byline links have the byline as title, and for ninjs 2.x the
+	// byline role as role.
+	Byline LinkType `json:"byline"`
+	// Subject links have the subject name as title, the URI as URI, and
+	// the code as value. Other scalar properties are kept as data.
+	Subject LinkType `json:"subject"`
+	// Association is the link rel for associations, and the type used
+	// for associations with an item type that isn't in Types.
+	// Associations have the URI as URI, the headline as title, the name
+	// as name, and their renditions as links.
+	Association LinkType `json:"association"`
+	// Rendition links have the href as URL, the mimetype (contenttype in
+	// ninjs 2.x) as content type, the title as title and the name as name.
+	// Other scalar properties, like width and height, are kept as data.
+	Rendition LinkType `json:"rendition"`
+	// HTML is used to import HTML bodies as content.
+	HTML htmlcontent.ImportConfig `json:"html"`
+	// Renderer is used to render content as HTML bodies on export,
+	// defaults to htmlcontent.NewRenderer().
+	Renderer *htmlcontent.Renderer `json:"-"`
+}
+
+// DefaultConfig returns a configuration that maps the ninjs types text,
+// picture, graphic, video and audio to the corresponding core document types,
+// keeps other fields in a "ninjs/meta" block, and uses the default HTML import
+// configuration.
+func DefaultConfig() Config {
+	return Config{
+		Types: map[string]string{
+			"text":    "core/article",
+			"picture": "core/image",
+			"graphic": "core/graphic",
+			"video":   "core/video",
+			"audio":   "core/audio",
+		},
+		MetaType:    "ninjs/meta",
+		Byline:      LinkType{Type: "core/author", Rel: "author"},
+		Subject:     LinkType{Type: "core/subject", Rel: "subject"},
+		Association: LinkType{Type: "core/association", Rel: "association"},
+		Rendition:   LinkType{Type: "core/rendition", Rel: "rendition"},
+		HTML:        htmlcontent.DefaultImportConfig(),
+	}
+}
+
+// ninjsType returns the ninjs item type for a document type.
+func (c Config) ninjsType(docType string) (string, bool) {
+	for _, k := range slices.Sorted(maps.Keys(c.Types)) {
+		if c.Types[k] == docType {
+			return k, true
+		}
+	}
+
+	return "", false
+}
+
+// Lost is something that couldn't be converted. The path is either a
+// ninjs field like "subjects[0].broader", or a document block like "links[2]".
+type Lost = convert.Lost
+
+// Report lists what was lost in a conversion.
+type Report = convert.Report
+
+// object is a decoded JSON object.
+type object map[string]any
+
+// take removes a field from the object and returns its value.
+func (o object) take(key string) (any, bool) {
+	v, ok := o[key]
+
+	delete(o, key)
+
+	return v, ok && v != nil
+}
+
+// sortedKeys returns the remaining keys of the object in order.
+func (o object) sortedKeys() []string {
+	return slices.Sorted(maps.Keys(o))
+}
+
+// scalar returns the string form of a string, number or boolean.
+func scalar(v any) (string, bool) {
+	switch value := v.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	}
+
+	return "", false
+}
+
+func asObject(v any) (object, bool) {
+	m, ok := v.(map[string]any)
+
+	return object(m), ok
+}
+
+func joinField(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func indexField(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
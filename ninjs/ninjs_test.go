@@ -0,0 +1,185 @@
+package ninjs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+	"github.com/ttab/newsdoc/ninjs"
+)
+
+func importItem(t *testing.T, name string) (newsdoc.Document, ninjs.Report) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	test.Mustf(t, err, "read ninjs item")
+
+	doc, report, err := ninjs.Import(data, ninjs.DefaultConfig())
+	test.Mustf(t, err, "import ninjs item")
+
+	return doc, report
+}
+
+func TestImport(t *testing.T) {
+	for _, name := range []string{"item-1", "item-2"} {
+		t.Run(name, func(t *testing.T) {
+			doc, report := importItem(t, name+".json")
+
+			test.AgainstGolden(t, test.Regenerate(), doc,
+				filepath.Join("testdata", "TestImport", name+"-doc.json"))
+
+			test.AgainstGolden(t, test.Regenerate(), report,
+				filepath.Join("testdata", "TestImport", name+"-report.json"))
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	var doc newsdoc.Document
+
+	err := test.UnmarshalFile(filepath.Join("testdata", "document.json"), &doc)
+	test.Mustf(t, err, "unmarshal document")
+
+	versions := map[string]ninjs.Version{
+		"v1": ninjs.Version1,
+		"v2": ninjs.Version2,
+	}
+
+	for name, version := range versions {
+		t.Run(name, func(t *testing.T) {
+			data, report, err := ninjs.Export(doc, version, ninjs.DefaultConfig())
+			test.Mustf(t, err, "export document")
+
+			var item map[string]any
+
+			err = json.Unmarshal(data, &item)
+			test.Mustf(t, err, "unmarshal exported item")
+
+			test.AgainstGolden(t, test.Regenerate(), item,
+				filepath.Join("testdata", "TestExport", name+"-item.json"))
+
+			test.AgainstGolden(t, test.Regenerate(), report,
+				filepath.Join("testdata", "TestExport", name+"-report.json"))
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	cases := []struct {
+		Item    string
+		Version ninjs.Version
+	}{
+		{Item: "item-1", Version: ninjs.Version1},
+		{Item: "item-1", Version: ninjs.Version2},
+		{Item: "item-2", Version: ninjs.Version2},
+	}
+
+	for _, c := range cases {
+		doc, _ := importItem(t, c.Item+".json")
+
+		data, report, err := ninjs.Export(doc, c.Version, ninjs.DefaultConfig())
+		test.Mustf(t, err, "export %s as version %d", c.Item, c.Version)
+
+		test.EqualDiffWithOptionsf(t, ninjs.Report{}, report, nil,
+			"%s must export to version %d without loss", c.Item, c.Version)
+
+		got, _, err := ninjs.Import(data, ninjs.DefaultConfig())
+		test.Mustf(t, err, "import exported %s", c.Item)
+
+		test.EqualDiffWithOptionsf(t, doc, got, nil,
+			"%s must survive a round trip through version %d", c.Item, c.Version)
+	}
+
+	// Bylines don't have roles in ninjs 1.x.
+	doc, _ := importItem(t, "item-2.json")
+
+	_, report, err := ninjs.Export(doc, ninjs.Version1, ninjs.DefaultConfig())
+	test.Mustf(t, err, "export item-2 as version 1")
+
+	want := ninjs.Report{
+		Lost: []ninjs.Lost{
+			{Path: "links[0].role", Reason: "no ninjs field for the block role"},
+			{Path: "links[1].role", Reason: "no ninjs field for the block role"},
+		},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, report, nil,
+		"must report the lost byline roles")
+}
+
+func TestExportVersion(t *testing.T) {
+	_, _, err := ninjs.Export(newsdoc.Document{}, 3, ninjs.DefaultConfig())
+	if err == nil {
+		t.Fatal("expected an error for an unsupported version")
+	}
+
+	t.Logf("got expected error: %v", err)
+}
+
+func TestImportNonScalar(t *testing.T) {
+	data := []byte(`{
+  "uri": "http://example.com/item/1",
+  "headline": {"en": "Hello"},
+  "body_html": ["<p>a</p>"]
+}`)
+
+	doc, report, err := ninjs.Import(data, ninjs.DefaultConfig())
+	test.Mustf(t, err, "import ninjs item")
+
+	want := ninjs.Report{
+		Lost: []ninjs.Lost{
+			{Path: "headline", Reason: "expected a string"},
+			{Path: "body_html", Reason: "expected a string"},
+		},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, report, nil,
+		"must report the non-scalar fields")
+
+	if doc.Title != "" || len(doc.Content) != 0 {
+		t.Fatalf("expected no title or content, got %q and %d blocks",
+			doc.Title, len(doc.Content))
+	}
+}
+
+func TestExportNumbers(t *testing.T) {
+	values := map[string]any{
+		"4":     json.Number("4"),
+		"-1.5":  json.Number("-1.5"),
+		"1e3":   json.Number("1e3"),
+		"Inf":   "Inf",
+		"NaN":   "NaN",
+		"0x1p3": "0x1p3",
+		"1_000": "1_000",
+		"+1":    "+1",
+		"":      "",
+	}
+
+	for value, want := range values {
+		doc := newsdoc.Document{
+			Meta: []newsdoc.Block{{
+				Type: "ninjs/meta",
+				Data: newsdoc.DataMap{"urgency": value},
+			}},
+		}
+
+		data, _, err := ninjs.Export(doc, ninjs.Version2, ninjs.DefaultConfig())
+		test.Mustf(t, err, "export urgency %q", value)
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+
+		dec.UseNumber()
+
+		var item map[string]any
+
+		err = dec.Decode(&item)
+		test.Mustf(t, err, "decode exported item")
+
+		test.EqualDiffWithOptionsf(t, want, item["urgency"], nil,
+			"urgency %q must be exported as the right type", value)
+	}
+}
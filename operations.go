@@ -1,6 +1,7 @@
 package newsdoc
 
 import (
+	"iter"
 	"slices"
 )
 
@@ -32,6 +33,47 @@ func AllBlocks(list []Block, selector BlockMatcher) []Block {
 	return res
 }
 
+// MatchingBlocks returns an iterator over the index and block of each block
+// matching the selector.
+func MatchingBlocks(list []Block, selector BlockMatcher) iter.Seq2[int, Block] {
+	return func(yield func(int, Block) bool) {
+		for i := range list {
+			if !selector.Match(list[i]) {
+				continue
+			}
+
+			if !yield(i, list[i]) {
+				return
+			}
+		}
+	}
+}
+
+// MatchingIndices returns an iterator over the indices of the blocks matching
+// the selector.
+func MatchingIndices(list []Block, selector BlockMatcher) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := range MatchingBlocks(list, selector) {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// MutableBlocks returns an iterator over pointers to the blocks matching the
+// selector, changes made through the pointers are made to the blocks in the
+// list.
+func MutableBlocks(list []Block, selector BlockMatcher) iter.Seq[*Block] {
+	return func(yield func(*Block) bool) {
+		for i := range MatchingIndices(list, selector) {
+			if !yield(&list[i]) {
+				return
+			}
+		}
+	}
+}
+
 // DropBlocks removes all blocks matching the selector.
 func DropBlocks(list []Block, selector BlockMatcher) []Block {
 	return slices.DeleteFunc(list, selector.Match)
@@ -125,3 +167,128 @@ func AddOrReplaceBlock(
 		return insert
 	})
 }
+
+// InsertBefore inserts the blocks before the first block matching the
+// selector. The blocks are appended to the end of the list if no block
+// matches.
+func InsertBefore(
+	list []Block, selector BlockMatcher, blocks ...Block,
+) []Block {
+	idx := slices.IndexFunc(list, selector.Match)
+	if idx == -1 {
+		return append(list, blocks...)
+	}
+
+	return slices.Insert(list, idx, blocks...)
+}
+
+// InsertAfter inserts the blocks after the first block matching the
+// selector. The blocks are appended to the end of the list if no block
+// matches.
+func InsertAfter(
+	list []Block, selector BlockMatcher, blocks ...Block,
+) []Block {
+	idx := slices.IndexFunc(list, selector.Match)
+	if idx == -1 {
+		return append(list, blocks...)
+	}
+
+	return slices.Insert(list, idx+1, blocks...)
+}
+
+// BlockPosition describes where in a list a block should be placed, see
+// MoveBlock().
+type BlockPosition struct {
+	anchor BlockMatcher
+	after  bool
+	index  int
+}
+
+// PositionFirst places a block first in the list.
+func PositionFirst() BlockPosition {
+	return BlockPosition{index: 0}
+}
+
+// PositionLast places a block last in the list.
+func PositionLast() BlockPosition {
+	return BlockPosition{index: -1}
+}
+
+// PositionIndex places a block at the given index in the list. Indexes
+// beyond the end of the list place the block last.
+func PositionIndex(index int) BlockPosition {
+	return BlockPosition{index: max(index, 0)}
+}
+
+// PositionBefore places a block before the first block matching the anchor.
+func PositionBefore(anchor BlockMatcher) BlockPosition {
+	return BlockPosition{anchor: anchor}
+}
+
+// PositionAfter places a block after the first block matching the anchor.
+func PositionAfter(anchor BlockMatcher) BlockPosition {
+	return BlockPosition{anchor: anchor, after: true}
+}
+
+// resolve returns the insertion index for the position in the list once the
+// block at index skip has been taken out of it, or false if the anchor
+// doesn't match any other block.
+func (p BlockPosition) resolve(list []Block, skip int) (int, bool) {
+	size := len(list) - 1
+
+	if p.anchor == nil {
+		if p.index < 0 || p.index > size {
+			return size, true
+		}
+
+		return p.index, true
+	}
+
+	for i := range list {
+		if i == skip || !p.anchor.Match(list[i]) {
+			continue
+		}
+
+		if i > skip {
+			i--
+		}
+
+		if p.after {
+			i++
+		}
+
+		return i, true
+	}
+
+	return 0, false
+}
+
+// MoveBlock moves the first block matching the selector to the given
+// position. The anchor of a relative position is never the moved block
+// itself. The list is left unchanged if no block matches the selector or the
+// anchor, and the returned bool reports whether the block was moved.
+func MoveBlock(
+	list []Block, selector BlockMatcher, to BlockPosition,
+) ([]Block, bool) {
+	from := slices.IndexFunc(list, selector.Match)
+	if from == -1 {
+		return list, false
+	}
+
+	idx, ok := to.resolve(list, from)
+	if !ok {
+		return list, false
+	}
+
+	block := list[from]
+
+	if idx < from {
+		copy(list[idx+1:from+1], list[idx:from])
+	} else {
+		copy(list[from:idx], list[from+1:idx+1])
+	}
+
+	list[idx] = block
+
+	return list, true
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
 )
 
 const (
@@ -357,3 +358,204 @@ func TestAddOrReplaceBlockAdd(t *testing.T) {
 		t.Errorf("expected title 'New Video', got %q", video.Title)
 	}
 }
+
+func TestInsertBefore(t *testing.T) {
+	result := newsdoc.InsertBefore(
+		sampleBlocks(), newsdoc.BlocksWithType("core/image"),
+		newsdoc.Block{Type: "core/video"},
+	)
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"Title", "Paragraph 1", "video", "image", "Paragraph 2", "embed",
+	}, blockLabels(result), nil, "insert video before image")
+}
+
+func TestInsertAfter(t *testing.T) {
+	result := newsdoc.InsertAfter(
+		sampleBlocks(), newsdoc.BlocksWithType(coreText),
+		newsdoc.Block{Type: "core/byline"},
+		newsdoc.Block{Type: "core/dateline"},
+	)
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"Title", "byline", "dateline", "Paragraph 1", "image",
+		"Paragraph 2", "embed",
+	}, blockLabels(result), nil, "insert blocks after first text")
+}
+
+func TestInsertNoMatch(t *testing.T) {
+	video := newsdoc.Block{Type: "core/video"}
+
+	before := newsdoc.InsertBefore(
+		sampleBlocks(), newsdoc.BlocksWithType("core/video"), video)
+	after := newsdoc.InsertAfter(
+		sampleBlocks(), newsdoc.BlocksWithType("core/video"), video)
+
+	for _, result := range [][]newsdoc.Block{before, after} {
+		if len(result) != 6 || result[5].Type != "core/video" {
+			t.Errorf("expected the block to be appended, got %v",
+				blockLabels(result))
+		}
+	}
+}
+
+func TestMoveBlock(t *testing.T) {
+	embed := newsdoc.BlocksWithType("core/embed")
+	image := newsdoc.BlocksWithType("core/image")
+	body := newsdoc.BlocksWithRole("body")
+
+	cases := map[string]struct {
+		Selector newsdoc.BlockMatcher
+		To       newsdoc.BlockPosition
+		Expect   []string
+	}{
+		"first": {
+			Selector: embed,
+			To:       newsdoc.PositionFirst(),
+			Expect:   []string{"embed", "Title", "Paragraph 1", "image", "Paragraph 2"},
+		},
+		"last": {
+			Selector: image,
+			To:       newsdoc.PositionLast(),
+			Expect:   []string{"Title", "Paragraph 1", "Paragraph 2", "embed", "image"},
+		},
+		"index": {
+			Selector: embed,
+			To:       newsdoc.PositionIndex(1),
+			Expect:   []string{"Title", "embed", "Paragraph 1", "image", "Paragraph 2"},
+		},
+		"index beyond end": {
+			Selector: image,
+			To:       newsdoc.PositionIndex(100),
+			Expect:   []string{"Title", "Paragraph 1", "Paragraph 2", "embed", "image"},
+		},
+		"before": {
+			Selector: embed,
+			To:       newsdoc.PositionBefore(image),
+			Expect:   []string{"Title", "Paragraph 1", "embed", "image", "Paragraph 2"},
+		},
+		"after": {
+			Selector: newsdoc.BlocksWithRole("heading"),
+			To:       newsdoc.PositionAfter(embed),
+			Expect:   []string{"Paragraph 1", "image", "Paragraph 2", "embed", "Title"},
+		},
+		"anchor skips moved block": {
+			Selector: body,
+			To:       newsdoc.PositionAfter(body),
+			Expect:   []string{"Title", "image", "Paragraph 2", "Paragraph 1", "embed"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, ok := newsdoc.MoveBlock(sampleBlocks(), c.Selector, c.To)
+			if !ok {
+				t.Fatal("expected the block to be moved")
+			}
+
+			test.EqualDiffWithOptionsf(t, c.Expect, blockLabels(result), nil, "block order")
+		})
+	}
+}
+
+func TestMoveBlockNoMatch(t *testing.T) {
+	blocks := sampleBlocks()
+	expected := blockLabels(blocks)
+
+	_, ok := newsdoc.MoveBlock(blocks,
+		newsdoc.BlocksWithType("core/video"), newsdoc.PositionFirst())
+	if ok {
+		t.Error("should not move a block when none matches")
+	}
+
+	_, ok = newsdoc.MoveBlock(blocks,
+		newsdoc.BlocksWithType("core/image"),
+		newsdoc.PositionBefore(newsdoc.BlocksWithType("core/video")))
+	if ok {
+		t.Error("should not move a block when the anchor doesn't match")
+	}
+
+	test.EqualDiffWithOptionsf(t, expected, blockLabels(blocks), nil, "block order")
+}
+
+func blockLabels(list []newsdoc.Block) []string {
+	res := make([]string, len(list))
+
+	for i, b := range list {
+		switch {
+		case b.Type == coreText:
+			res[i] = b.Value
+		default:
+			res[i] = b.Type[len("core/"):]
+		}
+	}
+
+	return res
+}
+
+func TestMatchingBlocks(t *testing.T) {
+	blocks := sampleBlocks()
+
+	var (
+		indices []int
+		values  []string
+	)
+
+	for i, b := range newsdoc.MatchingBlocks(blocks, newsdoc.BlocksWithType(coreText)) {
+		indices = append(indices, i)
+		values = append(values, b.Value)
+	}
+
+	test.EqualDiffWithOptionsf(t, []int{0, 1, 3}, indices, nil, "indices")
+	test.EqualDiffWithOptionsf(t,
+		[]string{"Title", "Paragraph 1", "Paragraph 2"}, values, nil,
+		"values")
+}
+
+func TestMatchingIndicesBreak(t *testing.T) {
+	blocks := sampleBlocks()
+
+	var indices []int
+
+	for i := range newsdoc.MatchingIndices(blocks, newsdoc.BlocksWithRole("body")) {
+		indices = append(indices, i)
+
+		break
+	}
+
+	test.EqualDiffWithOptionsf(t, []int{1}, indices, nil, "indices")
+}
+
+func TestMutableBlocks(t *testing.T) {
+	blocks := sampleBlocks()
+
+	for b := range newsdoc.MutableBlocks(blocks, newsdoc.BlocksWithRole("body")) {
+		b.Name = altered
+	}
+
+	for _, b := range blocks {
+		if (b.Role == "body") != (b.Name == altered) {
+			t.Errorf("only body blocks should have been altered, got %q with name %q",
+				b.Value, b.Name)
+		}
+	}
+}
+
+func BenchmarkMatchingBlocks(b *testing.B) {
+	blocks := sampleBlocks()
+	matcher := newsdoc.BlocksWithType(coreText)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		var n int
+
+		for range newsdoc.MatchingBlocks(blocks, matcher) {
+			n++
+		}
+
+		if n != 3 {
+			b.Fatalf("expected 3 matches, got %d", n)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package newsdoc
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// BlockOrdering is a declarative sort order for blocks. Blocks are compared
+// by each key in turn until one of them tells them apart.
+type BlockOrdering []SortKey
+
+// SortKey compares blocks by a block attribute or a data value.
+type SortKey struct {
+	// Attribute is the name of the block attribute to compare by, f.ex.
+	// "type" or "role".
+	Attribute string `json:"attribute,omitempty"`
+	// Data is the data key to compare by. Either Attribute or Data must
+	// be set.
+	Data string `json:"data,omitempty"`
+	// Order lists values in the order they should appear. Values that
+	// aren't in the list are placed after the listed ones, and compare as
+	// equal among themselves so that they keep their relative order. Add
+	// another key to sort them by value.
+	Order []string `json:"order,omitempty"`
+	// Numeric compares values as numbers. Values that aren't numbers are
+	// placed after the ones that are.
+	Numeric bool `json:"numeric,omitempty"`
+	// Descending reverses the order of the key. Values that aren't
+	// listed in Order, or aren't numbers, are still placed last.
+	Descending bool `json:"descending,omitempty"`
+}
+
+// Validate checks that every key refers to exactly one known attribute or a
+// data key.
+func (o BlockOrdering) Validate() error {
+	for i, key := range o {
+		switch {
+		case key.Attribute != "" && key.Data != "":
+			return fmt.Errorf(
+				"sort key %d: both attribute and data key set", i+1)
+		case key.Attribute == "" && key.Data == "":
+			return fmt.Errorf(
+				"sort key %d: no attribute or data key set", i+1)
+		case key.Attribute != "" && !isBlockAttribute(key.Attribute):
+			return fmt.Errorf(
+				"sort key %d: unknown attribute %q", i+1, key.Attribute)
+		}
+	}
+
+	return nil
+}
+
+// SortBlocks sorts the list in place according to the ordering. The sort is
+// stable, so blocks that the ordering consider equal keep their relative
+// order.
+func SortBlocks(list []Block, ordering BlockOrdering) error {
+	if len(ordering) == 0 {
+		return errors.New("empty block ordering")
+	}
+
+	err := ordering.Validate()
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(list, ordering.Compare)
+
+	return nil
+}
+
+// Compare compares two blocks according to the ordering, returning -1 if a
+// should be placed before b, 1 if after, and 0 if they are equal.
+func (o BlockOrdering) Compare(a Block, b Block) int {
+	for _, key := range o {
+		c := key.Compare(a, b)
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// Compare compares two blocks by the key.
+func (k SortKey) Compare(a Block, b Block) int {
+	av, bv := k.value(a), k.value(b)
+
+	// Values that the key can't rank are placed last regardless of
+	// direction.
+	aLast, bLast := k.placedLast(av), k.placedLast(bv)
+
+	switch {
+	case aLast && !bLast:
+		return 1
+	case bLast && !aLast:
+		return -1
+	}
+
+	c := k.compareValues(av, bv)
+	if k.Descending {
+		return -c
+	}
+
+	return c
+}
+
+func (k SortKey) value(b Block) string {
+	if k.Data != "" {
+		return getBlockData(b, k.Data)
+	}
+
+	return getBlockAttribute(b, k.Attribute)
+}
+
+// placedLast reports whether the value isn't listed in the order, or isn't a
+// number when the key is numeric.
+func (k SortKey) placedLast(v string) bool {
+	if len(k.Order) > 0 {
+		return !slices.Contains(k.Order, v)
+	}
+
+	if k.Numeric {
+		_, err := strconv.ParseFloat(v, 64)
+
+		return err != nil
+	}
+
+	return false
+}
+
+// compareValues compares two values that are either both placed last, or
+// both ranked by the key.
+func (k SortKey) compareValues(a string, b string) int {
+	if len(k.Order) > 0 {
+		ai := slices.Index(k.Order, a)
+		bi := slices.Index(k.Order, b)
+
+		return cmp.Compare(ai, bi)
+	}
+
+	if !k.Numeric {
+		return cmp.Compare(a, b)
+	}
+
+	an, aErr := strconv.ParseFloat(a, 64)
+	bn, bErr := strconv.ParseFloat(b, 64)
+
+	if aErr == nil && bErr == nil {
+		return cmp.Compare(an, bn)
+	}
+
+	return cmp.Compare(a, b)
+}
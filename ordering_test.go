@@ -0,0 +1,153 @@
+package newsdoc_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func TestSortBlocks(t *testing.T) {
+	blocks := []newsdoc.Block{
+		{Type: coreText, Role: "body", Value: "Paragraph 1"},
+		{Type: "core/byline", Value: "Byline"},
+		{Type: coreText, Role: "preamble", Value: "Lead"},
+		{Type: "core/image", Value: "Image 2", Data: newsdoc.DataMap{"position": "10"}},
+		{Type: coreText, Role: "heading", Value: "Headline"},
+		{Type: "core/image", Value: "Image 1", Data: newsdoc.DataMap{"position": "9"}},
+		{Type: coreText, Role: "body", Value: "Paragraph 2"},
+	}
+
+	ordering := newsdoc.BlockOrdering{
+		{Attribute: "role", Order: []string{"heading", "preamble"}},
+		{Attribute: "type", Order: []string{"core/byline"}},
+		{Data: "position", Numeric: true},
+	}
+
+	err := newsdoc.SortBlocks(blocks, ordering)
+	test.Mustf(t, err, "sort blocks")
+
+	got := make([]string, len(blocks))
+
+	for i, b := range blocks {
+		got[i] = b.Value
+	}
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"Headline", "Lead", "Byline",
+		"Image 1", "Image 2", "Paragraph 1", "Paragraph 2",
+	}, got, nil, "sorted blocks")
+}
+
+func TestSortBlocksKeepsUnlistedOrder(t *testing.T) {
+	blocks := []newsdoc.Block{
+		{Type: coreText, Value: "Paragraph 1"},
+		{Type: "core/image", Value: "Image"},
+		{Type: "core/heading", Value: "Headline"},
+		{Type: coreText, Value: "Paragraph 2"},
+	}
+
+	err := newsdoc.SortBlocks(blocks, newsdoc.BlockOrdering{
+		{Attribute: "type", Order: []string{"core/heading"}},
+	})
+	test.Mustf(t, err, "sort blocks")
+
+	got := make([]string, len(blocks))
+
+	for i, b := range blocks {
+		got[i] = b.Value
+	}
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"Headline", "Paragraph 1", "Image", "Paragraph 2",
+	}, got, nil, "unlisted blocks must keep their document order")
+}
+
+func TestSortBlocksDescending(t *testing.T) {
+	blocks := []newsdoc.Block{
+		{Value: "b"},
+		{Value: "c"},
+		{Value: "a"},
+	}
+
+	err := newsdoc.SortBlocks(blocks, newsdoc.BlockOrdering{
+		{Attribute: "value", Descending: true},
+	})
+	test.Mustf(t, err, "sort blocks")
+
+	if blocks[0].Value != "c" || blocks[2].Value != "a" {
+		t.Errorf("expected descending order, got %v", blocks)
+	}
+}
+
+func TestSortBlocksDescendingKeepsUnlistedLast(t *testing.T) {
+	blocks := []newsdoc.Block{
+		{Type: coreText, Value: "Paragraph 1"},
+		{Type: "core/heading", Value: "Headline"},
+		{Type: "core/image", Value: "Image"},
+		{Type: coreText, Value: "Paragraph 2"},
+		{Type: "core/factbox", Value: "Factbox"},
+		{Value: "Three", Data: newsdoc.DataMap{"n": "3"}},
+		{Value: "NaN", Data: newsdoc.DataMap{"n": "x"}},
+		{Value: "Ten", Data: newsdoc.DataMap{"n": "10"}},
+	}
+
+	err := newsdoc.SortBlocks(blocks[:5], newsdoc.BlockOrdering{
+		{
+			Attribute:  "type",
+			Order:      []string{"core/heading", "core/factbox"},
+			Descending: true,
+		},
+	})
+	test.Mustf(t, err, "sort blocks by order")
+
+	err = newsdoc.SortBlocks(blocks[5:], newsdoc.BlockOrdering{
+		{Data: "n", Numeric: true, Descending: true},
+	})
+	test.Mustf(t, err, "sort blocks by number")
+
+	got := make([]string, len(blocks))
+
+	for i, b := range blocks {
+		got[i] = b.Value
+	}
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"Factbox", "Headline", "Paragraph 1", "Image", "Paragraph 2",
+		"Ten", "Three", "NaN",
+	}, got, nil, "unranked blocks must be placed last in descending order")
+}
+
+func TestSortBlocksInvalidOrdering(t *testing.T) {
+	cases := map[string]newsdoc.BlockOrdering{
+		"empty":             nil,
+		"no key":            {{Numeric: true}},
+		"both keys":         {{Attribute: "type", Data: "text"}},
+		"unknown attribute": {{Attribute: "colour"}},
+	}
+
+	for name, ordering := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := newsdoc.SortBlocks(sampleBlocks(), ordering)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestBlockOrderingJSON(t *testing.T) {
+	var ordering newsdoc.BlockOrdering
+
+	err := json.Unmarshal([]byte(`[
+  {"attribute": "type", "order": ["core/heading"]},
+  {"data": "position", "numeric": true, "descending": true}
+]`), &ordering)
+	test.Mustf(t, err, "unmarshal ordering")
+
+	test.EqualDiffWithOptionsf(t, newsdoc.BlockOrdering{
+		{Attribute: "type", Order: []string{"core/heading"}},
+		{Data: "position", Numeric: true, Descending: true},
+	}, ordering, nil, "unmarshalled ordering")
+}
@@ -0,0 +1,110 @@
+package newsdoc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BlockPath is the location of a block in a document, expressed as the chain
+// of block kinds and indexes from the document root. The string form of a
+// path is f.ex. "meta[5].links[1]".
+type BlockPath []PathStep
+
+// PathStep is a step in a BlockPath.
+type PathStep struct {
+	Kind  BlockKind
+	Index int
+}
+
+// String returns the path in the form "meta[5].links[1]".
+func (p BlockPath) String() string {
+	var sb strings.Builder
+
+	for i, step := range p {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+
+		sb.WriteString(string(step.Kind))
+		sb.WriteByte('[')
+		sb.WriteString(strconv.Itoa(step.Index))
+		sb.WriteByte(']')
+	}
+
+	return sb.String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p BlockPath) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *BlockPath) UnmarshalText(text []byte) error {
+	path, err := ParseBlockPath(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = path
+
+	return nil
+}
+
+// ParseBlockPath parses a path in the form "meta[5].links[1]". An empty string
+// is parsed as an empty path.
+func ParseBlockPath(s string) (BlockPath, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ".")
+	path := make(BlockPath, 0, len(parts))
+
+	for _, part := range parts {
+		kind, idx, ok := strings.Cut(part, "[")
+		if !ok || !strings.HasSuffix(idx, "]") {
+			return nil, fmt.Errorf("invalid path step: %q", part)
+		}
+
+		switch BlockKind(kind) {
+		case BlockKindMeta, BlockKindLinks, BlockKindContent:
+		default:
+			return nil, fmt.Errorf("unknown block kind: %s", kind)
+		}
+
+		n, err := strconv.Atoi(idx[:len(idx)-1])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid index in path step: %q", part)
+		}
+
+		path = append(path, PathStep{
+			Kind:  BlockKind(kind),
+			Index: n,
+		})
+	}
+
+	return path, nil
+}
+
+// Get returns the block at the path.
+func (p BlockPath) Get(doc Document) (Block, bool) {
+	if len(p) == 0 {
+		return Block{}, false
+	}
+
+	blocks := documentBlocks(doc, p[0].Kind)
+
+	for i, step := range p {
+		if i > 0 {
+			blocks = childBlocks(blocks[p[i-1].Index], step.Kind)
+		}
+
+		if step.Index < 0 || step.Index >= len(blocks) {
+			return Block{}, false
+		}
+	}
+
+	return blocks[p[len(p)-1].Index], true
+}
@@ -0,0 +1,102 @@
+package newsdoc_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func TestBlockPathString(t *testing.T) {
+	path := newsdoc.BlockPath{
+		{Kind: newsdoc.BlockKindMeta, Index: 5},
+		{Kind: newsdoc.BlockKindLinks, Index: 1},
+	}
+
+	if path.String() != "meta[5].links[1]" {
+		t.Errorf("unexpected path string %q", path.String())
+	}
+
+	parsed, err := newsdoc.ParseBlockPath(path.String())
+	test.Mustf(t, err, "parse path")
+
+	test.EqualDiffWithOptionsf(t, path, parsed, nil, "parsed path")
+}
+
+func TestParseBlockPathErrors(t *testing.T) {
+	cases := []string{
+		"meta",
+		"meta[x]",
+		"meta[-1]",
+		"widgets[0]",
+		"meta[0]..links[1]",
+		"meta[0",
+	}
+
+	for _, c := range cases {
+		_, err := newsdoc.ParseBlockPath(c)
+		if err == nil {
+			t.Errorf("expected error for %q", c)
+		}
+	}
+}
+
+func TestBlockPathJSON(t *testing.T) {
+	source := newsdoc.BlockSource{
+		Path: newsdoc.BlockPath{
+			{Kind: newsdoc.BlockKindContent, Index: 2},
+		},
+		ID: "abc",
+	}
+
+	data, err := json.Marshal(source)
+	test.Mustf(t, err, "marshal source")
+
+	if string(data) != `{"Path":"content[2]","ID":"abc"}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	var got newsdoc.BlockSource
+
+	err = json.Unmarshal(data, &got)
+	test.Mustf(t, err, "unmarshal source")
+
+	test.EqualDiffWithOptionsf(t, source, got, nil, "unmarshalled source")
+}
+
+func TestBlockPathGet(t *testing.T) {
+	doc := newsdoc.Document{
+		Meta: []newsdoc.Block{
+			{Type: "core/a"},
+			{
+				Type: "core/b",
+				Links: []newsdoc.Block{
+					{Rel: "first"},
+					{Rel: "second"},
+				},
+			},
+		},
+	}
+
+	path, err := newsdoc.ParseBlockPath("meta[1].links[1]")
+	test.Mustf(t, err, "parse path")
+
+	b, ok := path.Get(doc)
+	if !ok {
+		t.Fatal("expected to find block")
+	}
+
+	if b.Rel != "second" {
+		t.Errorf("expected rel 'second', got %q", b.Rel)
+	}
+
+	for _, missing := range []string{"meta[2]", "meta[0].links[0]", "links[0]", ""} {
+		path, err := newsdoc.ParseBlockPath(missing)
+		test.Mustf(t, err, "parse path %q", missing)
+
+		if _, ok := path.Get(doc); ok {
+			t.Errorf("expected %q to not resolve", missing)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package newsdoc
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TextKind controls how a block is separated from the surrounding text in
+// PlainText().
+type TextKind int
+
+const (
+	// TextParagraph is a block of running text.
+	TextParagraph TextKind = iota
+	// TextHeading is a heading.
+	TextHeading
+	// TextListItem is an item in a list, consecutive list items are
+	// separated with the list item separator.
+	TextListItem
+)
+
+// TextSpec describes the text of a block type.
+type TextSpec struct {
+	// DataKeys are the data keys that carry text, in the order they
+	// should appear in. Each data key is treated as a separate block of
+	// text.
+	DataKeys []string
+	// Kind is the kind of text.
+	Kind TextKind
+	// RoleKinds overrides Kind for blocks with the given roles, f.ex.
+	// "heading-1" for core/text blocks.
+	RoleKinds map[string]TextKind
+}
+
+func (s TextSpec) kind(b Block) TextKind {
+	k, ok := s.RoleKinds[b.Role]
+	if ok {
+		return k
+	}
+
+	return s.Kind
+}
+
+// TextRegistry maps block types to text specifications.
+type TextRegistry struct {
+	types map[string]TextSpec
+}
+
+// NewTextRegistry creates an empty text registry.
+func NewTextRegistry() *TextRegistry {
+	return &TextRegistry{
+		types: make(map[string]TextSpec),
+	}
+}
+
+// DefaultTextRegistry creates a text registry for the common content block
+// types: core/text with heading roles, image captions, factboxes, and list
+// items.
+func DefaultTextRegistry() *TextRegistry {
+	r := NewTextRegistry()
+
+	r.Register("core/text", TextSpec{
+		DataKeys: []string{"text"},
+		Kind:     TextParagraph,
+		RoleKinds: map[string]TextKind{
+			"heading-1": TextHeading,
+			"heading-2": TextHeading,
+			"heading-3": TextHeading,
+			"heading-4": TextHeading,
+			"heading-5": TextHeading,
+			"heading-6": TextHeading,
+			"list-item": TextListItem,
+		},
+	})
+	r.Register("core/paragraph", TextSpec{
+		DataKeys: []string{"text"},
+		Kind:     TextParagraph,
+	})
+	r.Register("core/heading", TextSpec{
+		DataKeys: []string{"text"},
+		Kind:     TextHeading,
+	})
+	r.Register("core/list-item", TextSpec{
+		DataKeys: []string{"text"},
+		Kind:     TextListItem,
+	})
+	r.Register("core/image", TextSpec{
+		DataKeys: []string{"text"},
+		Kind:     TextParagraph,
+	})
+	r.Register("core/factbox", TextSpec{
+		DataKeys: []string{"title", "text"},
+		Kind:     TextParagraph,
+		RoleKinds: map[string]TextKind{
+			"heading": TextHeading,
+		},
+	})
+
+	return r
+}
+
+// Register sets the text specification for a block type.
+func (r *TextRegistry) Register(blockType string, spec TextSpec) {
+	r.types[blockType] = spec
+}
+
+// Unregister removes the text specification for a block type, blocks of the
+// type will not contribute any text.
+func (r *TextRegistry) Unregister(blockType string) {
+	delete(r.types, blockType)
+}
+
+// Lookup returns the text specification for a block type.
+func (r *TextRegistry) Lookup(blockType string) (TextSpec, bool) {
+	s, ok := r.types[blockType]
+
+	return s, ok
+}
+
+// PlainTextOptions controls PlainText().
+type PlainTextOptions struct {
+	// Registry is the text registry to use, defaults to
+	// DefaultTextRegistry().
+	Registry *TextRegistry
+	// ParagraphSeparator is used after paragraphs, and after the last
+	// item of a list. Defaults to "\n\n".
+	ParagraphSeparator string
+	// HeadingSeparator is used after headings. Defaults to "\n\n".
+	HeadingSeparator string
+	// ListItemSeparator is used between consecutive list items. Defaults
+	// to "\n".
+	ListItemSeparator string
+	// ListItemPrefix is written before each list item, f.ex. "- ".
+	ListItemPrefix string
+}
+
+// PlainTextResult is the text of a document.
+type PlainTextResult struct {
+	Text string
+	// Words is the number of whitespace separated words in the text.
+	Words int
+	// Characters is the number of characters in the text, including
+	// whitespace and separators.
+	Characters int
+	// NonSpaceCharacters is the number of characters in the text,
+	// excluding whitespace.
+	NonSpaceCharacters int
+}
+
+// PlainText extracts the text of the document content. The content is walked
+// in order, including the content of nested blocks, and the registry decides
+// which data keys carry text for each block type. Blocks of types that aren't
+// in the registry don't contribute text of their own, but their nested
+// content is still walked. Inline markup is removed from the text.
+func PlainText(doc Document, opts PlainTextOptions) PlainTextResult {
+	if opts.Registry == nil {
+		opts.Registry = DefaultTextRegistry()
+	}
+
+	if opts.ParagraphSeparator == "" {
+		opts.ParagraphSeparator = "\n\n"
+	}
+
+	if opts.HeadingSeparator == "" {
+		opts.HeadingSeparator = "\n\n"
+	}
+
+	if opts.ListItemSeparator == "" {
+		opts.ListItemSeparator = "\n"
+	}
+
+	w := textWriter{opts: opts}
+
+	w.writeBlocks(doc.Content)
+
+	text := w.buf.String()
+
+	res := PlainTextResult{
+		Text:       text,
+		Words:      len(strings.Fields(text)),
+		Characters: utf8.RuneCountInString(text),
+	}
+
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			res.NonSpaceCharacters++
+		}
+	}
+
+	return res
+}
+
+type textWriter struct {
+	opts     PlainTextOptions
+	buf      strings.Builder
+	started  bool
+	lastKind TextKind
+}
+
+func (w *textWriter) writeBlocks(blocks []Block) {
+	for _, b := range blocks {
+		spec, ok := w.opts.Registry.Lookup(b.Type)
+		if ok {
+			kind := spec.kind(b)
+
+			for _, key := range spec.DataKeys {
+				w.write(kind, StripMarkup(b.Data.Get(key, "")))
+			}
+		}
+
+		w.writeBlocks(b.Content)
+	}
+}
+
+func (w *textWriter) write(kind TextKind, text string) {
+	if text == "" {
+		return
+	}
+
+	if w.started {
+		switch {
+		case w.lastKind == TextListItem && kind == TextListItem:
+			w.buf.WriteString(w.opts.ListItemSeparator)
+		case w.lastKind == TextHeading:
+			w.buf.WriteString(w.opts.HeadingSeparator)
+		default:
+			w.buf.WriteString(w.opts.ParagraphSeparator)
+		}
+	}
+
+	if kind == TextListItem {
+		w.buf.WriteString(w.opts.ListItemPrefix)
+	}
+
+	w.buf.WriteString(text)
+
+	w.started = true
+	w.lastKind = kind
+}
+
+// StripMarkup removes inline HTML markup from text, decodes character
+// references and collapses whitespace. Line breaks (<br>) are kept as
+// newlines.
+func StripMarkup(text string) string {
+	var (
+		buf   strings.Builder
+		lines []string
+	)
+
+	for len(text) > 0 {
+		start := strings.IndexByte(text, '<')
+		if start == -1 {
+			buf.WriteString(text)
+
+			break
+		}
+
+		end := strings.IndexByte(text[start:], '>')
+		if end == -1 {
+			buf.WriteString(text)
+
+			break
+		}
+
+		buf.WriteString(text[:start])
+
+		if isLineBreakTag(text[start+1 : start+end]) {
+			lines = append(lines, buf.String())
+			buf.Reset()
+		}
+
+		text = text[start+end+1:]
+	}
+
+	lines = append(lines, buf.String())
+
+	for i := range lines {
+		lines[i] = strings.Join(strings.Fields(html.UnescapeString(lines[i])), " ")
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func isLineBreakTag(tag string) bool {
+	name := strings.TrimRight(tag, "/ ")
+
+	return strings.EqualFold(name, "br")
+}
@@ -0,0 +1,123 @@
+package newsdoc_test
+
+import (
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func textDocument() newsdoc.Document {
+	text := func(role, value string) newsdoc.Block {
+		return newsdoc.Block{
+			Type: coreText,
+			Role: role,
+			Data: newsdoc.DataMap{"text": value},
+		}
+	}
+
+	return newsdoc.Document{
+		Title: "Not part of the content",
+		Content: []newsdoc.Block{
+			text("heading-1", "Ice <em>hockey</em> final"),
+			text("preamble", "The final was decided in overtime."),
+			text("", "Fans  &amp; players<br>celebrated."),
+			{
+				Type: "core/image",
+				Data: newsdoc.DataMap{
+					"text":   "The winning goal.",
+					"credit": "Photographer",
+				},
+			},
+			text("list-item", "First period"),
+			text("list-item", `<a href="https://example.com">Second</a> period`),
+			{
+				Type: "core/factbox",
+				Data: newsdoc.DataMap{"title": "Facts"},
+				Content: []newsdoc.Block{
+					text("", "Nested text."),
+				},
+			},
+			{
+				Type: "core/unknown",
+				Data: newsdoc.DataMap{"text": "Skipped"},
+				Content: []newsdoc.Block{
+					text("", "Still included."),
+				},
+			},
+		},
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	res := newsdoc.PlainText(textDocument(), newsdoc.PlainTextOptions{
+		ListItemPrefix: "- ",
+	})
+
+	want := "Ice hockey final\n\n" +
+		"The final was decided in overtime.\n\n" +
+		"Fans & players\ncelebrated.\n\n" +
+		"The winning goal.\n\n" +
+		"- First period\n" +
+		"- Second period\n\n" +
+		"Facts\n\n" +
+		"Nested text.\n\n" +
+		"Still included."
+
+	test.EqualDiffWithOptionsf(t, want, res.Text, nil, "plain text")
+
+	if res.Words != 27 {
+		t.Errorf("expected 27 words, got %d", res.Words)
+	}
+
+	if res.Characters != len(want) {
+		t.Errorf("expected %d characters, got %d", len(want), res.Characters)
+	}
+
+	if res.NonSpaceCharacters != 136 {
+		t.Errorf("expected 136 non-space characters, got %d",
+			res.NonSpaceCharacters)
+	}
+}
+
+func TestPlainTextRegistry(t *testing.T) {
+	reg := newsdoc.DefaultTextRegistry()
+
+	reg.Unregister("core/image")
+	reg.Register("core/unknown", newsdoc.TextSpec{
+		DataKeys: []string{"text"},
+		Kind:     newsdoc.TextHeading,
+	})
+
+	res := newsdoc.PlainText(newsdoc.Document{
+		Content: []newsdoc.Block{
+			textDocument().Content[3],
+			textDocument().Content[7],
+		},
+	}, newsdoc.PlainTextOptions{
+		Registry:         reg,
+		HeadingSeparator: "\n",
+	})
+
+	test.EqualDiffWithOptionsf(t, "Skipped\nStill included.", res.Text, nil,
+		"plain text")
+}
+
+func TestStripMarkup(t *testing.T) {
+	cases := map[string]string{
+		"plain":                      "plain",
+		"<strong>bold</strong> text": "bold text",
+		"a &lt;tag&gt;":              "a <tag>",
+		"line<br/>break":             "line\nbreak",
+		"line<BR >break":             "line\nbreak",
+		"  spaced\n out  ":           "spaced out",
+		"1 < 2":                      "1 < 2",
+	}
+
+	for in, want := range cases {
+		got := newsdoc.StripMarkup(in)
+		if got != want {
+			t.Errorf("StripMarkup(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
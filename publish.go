@@ -0,0 +1,125 @@
+package newsdoc
+
+import (
+	"fmt"
+	"slices"
+)
+
+// PublishRule drops blocks from the published view of a document.
+type PublishRule struct {
+	// Name identifies the rule in reports.
+	Name string `json:"name"`
+	// Filter is a filter expression, see ParseMatcher(). Blocks anywhere
+	// in the document that match the filter are dropped together with
+	// their descendants.
+	Filter string `json:"filter"`
+	// Kinds restricts the rule to blocks of the given kinds, the rule
+	// applies to all kinds if empty.
+	Kinds []BlockKind `json:"kinds,omitempty"`
+}
+
+// DefaultPublishRules returns rules that drop blocks with the role
+// "internal", blocks that have the data value public set to "false", and
+// links flagged as internal.
+func DefaultPublishRules() []PublishRule {
+	return []PublishRule{
+		{
+			Name:   "internal-role",
+			Filter: "role='internal'",
+		},
+		{
+			Name:   "non-public",
+			Filter: "data.public='false'",
+		},
+		{
+			Name:   "internal-link",
+			Filter: "rel='internal' or sensitivity='internal'",
+			Kinds:  []BlockKind{BlockKindLinks},
+		},
+	}
+}
+
+// PublishView produces outward-facing copies of documents.
+type PublishView struct {
+	rules []publishRule
+}
+
+type publishRule struct {
+	PublishRule
+
+	matcher BlockMatcher
+}
+
+// NewPublishView creates a publish view from the rules.
+func NewPublishView(rules []PublishRule) (*PublishView, error) {
+	pv := PublishView{
+		rules: make([]publishRule, len(rules)),
+	}
+
+	for i, r := range rules {
+		m, err := ParseMatcher(r.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d %q: %w", i+1, r.Name, err)
+		}
+
+		for _, k := range r.Kinds {
+			switch k {
+			case BlockKindMeta, BlockKindLinks, BlockKindContent:
+			default:
+				return nil, fmt.Errorf("rule %d %q: unknown block kind %q",
+					i+1, r.Name, k)
+			}
+		}
+
+		pv.rules[i] = publishRule{
+			PublishRule: r,
+			matcher:     m,
+		}
+	}
+
+	return &pv, nil
+}
+
+// DroppedBlock is a block that was left out of a published document.
+type DroppedBlock struct {
+	// Path is the location of the block in the original document.
+	Path BlockPath
+	// Rule is the name of the rule that dropped the block.
+	Rule  string
+	Block Block
+}
+
+// Apply returns a copy of the document without the blocks that are dropped
+// by the rules, together with a list of the dropped blocks. The original
+// document is left untouched.
+func (pv *PublishView) Apply(doc Document) (Document, []DroppedBlock) {
+	var dropped []DroppedBlock
+
+	out := doc.Clone()
+
+	walkDocument(&out, newDeepOptions(nil), func(path BlockPath, b *Block) walkAction {
+		kind := path[len(path)-1].Kind
+
+		for _, r := range pv.rules {
+			if len(r.Kinds) > 0 && !slices.Contains(r.Kinds, kind) {
+				continue
+			}
+
+			if !r.matcher.Match(*b) {
+				continue
+			}
+
+			dropped = append(dropped, DroppedBlock{
+				Path:  path,
+				Rule:  r.Name,
+				Block: *b,
+			})
+
+			return walkDrop
+		}
+
+		return walkContinue
+	})
+
+	return out, dropped
+}
@@ -0,0 +1,92 @@
+package newsdoc_test
+
+import (
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func TestPublishView(t *testing.T) {
+	doc := loadPlanningDocument(t)
+
+	doc.Meta[5].Links = append(doc.Meta[5].Links, newsdoc.Block{
+		Type: "core/contact",
+		Rel:  "internal",
+	})
+
+	original := doc.Clone()
+
+	pv, err := newsdoc.NewPublishView(newsdoc.DefaultPublishRules())
+	test.Mustf(t, err, "create publish view")
+
+	published, dropped := pv.Apply(doc)
+
+	test.EqualDiffWithOptionsf(t, original, doc, test.EquateEmpty{}.CmpOpts(),
+		"the original document must be left untouched")
+
+	var got []string
+
+	for _, d := range dropped {
+		got = append(got, d.Path.String()+" "+d.Rule+" "+d.Block.Type)
+	}
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"meta[0] non-public core/planning-item",
+		"meta[2] internal-role core/description",
+		"meta[5].links[2] internal-link core/contact",
+	}, got, nil, "dropped blocks")
+
+	if len(published.Meta) != len(doc.Meta)-2 {
+		t.Errorf("expected %d meta blocks, got %d",
+			len(doc.Meta)-2, len(published.Meta))
+	}
+
+	_, hasDescription := newsdoc.FirstBlock(published.Meta,
+		newsdoc.BlocksWithType("core/description"))
+	if hasDescription {
+		t.Error("the internal description should have been dropped")
+	}
+}
+
+func TestPublishViewKinds(t *testing.T) {
+	doc := newsdoc.Document{
+		Meta: []newsdoc.Block{
+			{Type: "core/note", Sensitivity: internal},
+		},
+		Links: []newsdoc.Block{
+			{Rel: "author", Sensitivity: internal},
+		},
+	}
+
+	pv, err := newsdoc.NewPublishView(newsdoc.DefaultPublishRules())
+	test.Mustf(t, err, "create publish view")
+
+	published, _ := pv.Apply(doc)
+
+	if len(published.Meta) != 1 || len(published.Links) != 0 {
+		t.Errorf("expected the internal link rule to only apply to links, got %d meta and %d links",
+			len(published.Meta), len(published.Links))
+	}
+}
+
+func TestPublishViewInvalidRules(t *testing.T) {
+	cases := map[string]newsdoc.PublishRule{
+		"empty filter":   {Name: "empty"},
+		"invalid filter": {Name: "invalid", Filter: "type="},
+		"unknown kind": {
+			Name:   "kind",
+			Filter: "role='internal'",
+			Kinds:  []newsdoc.BlockKind{"attachments"},
+		},
+	}
+
+	for name, rule := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newsdoc.NewPublishView([]newsdoc.PublishRule{rule})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package newsdoc
+
+import (
+	"slices"
+)
+
+// RedactionAction is the action taken on blocks with a sensitivity level.
+type RedactionAction int
+
+const (
+	// RedactKeep leaves the block as it is.
+	RedactKeep RedactionAction = iota
+	// RedactDropBlock removes the block, but keeps its children of the
+	// same kind in its place, f.ex. the content blocks of a content
+	// block. Children of other kinds are removed together with the block.
+	RedactDropBlock
+	// RedactDropSubtree removes the block and all its descendants.
+	RedactDropSubtree
+	// RedactStripData removes data keys from the block.
+	RedactStripData
+	// RedactReplace replaces the block using a callback.
+	RedactReplace
+)
+
+// String returns the name of the action.
+func (a RedactionAction) String() string {
+	switch a {
+	case RedactKeep:
+		return "keep"
+	case RedactDropBlock:
+		return "drop-block"
+	case RedactDropSubtree:
+		return "drop-subtree"
+	case RedactStripData:
+		return "strip-data"
+	case RedactReplace:
+		return "replace"
+	}
+
+	return "unknown"
+}
+
+// RedactionRule describes how blocks with a sensitivity level are redacted.
+type RedactionRule struct {
+	Action RedactionAction
+	// DataKeys are the data keys that are removed by RedactStripData, all
+	// data is removed if no keys are given.
+	DataKeys []string
+	// Replace is called by RedactReplace with the block and its path in
+	// the original document. Returning false removes the block, as does
+	// a nil callback. The children of the returned block are redacted as
+	// well.
+	Replace func(path BlockPath, b Block) (Block, bool)
+}
+
+// RedactionPolicy describes how blocks are redacted based on their
+// sensitivity.
+type RedactionPolicy struct {
+	// Levels are the rules for each sensitivity level.
+	Levels map[string]RedactionRule
+	// Default is the rule for sensitivity levels that aren't listed in
+	// Levels. Blocks without a sensitivity level are always kept.
+	Default RedactionRule
+}
+
+func (p RedactionPolicy) rule(sensitivity string) RedactionRule {
+	if sensitivity == "" {
+		return RedactionRule{Action: RedactKeep}
+	}
+
+	r, ok := p.Levels[sensitivity]
+	if !ok {
+		return p.Default
+	}
+
+	return r
+}
+
+// RedactionReport lists the redactions made by Redact().
+type RedactionReport struct {
+	Entries []RedactionEntry
+	// Removed is the total number of removed blocks, including
+	// descendants of removed blocks.
+	Removed int
+}
+
+// RedactionEntry is a block that was redacted.
+type RedactionEntry struct {
+	// Path is the location of the block in the original document.
+	Path        BlockPath
+	Type        string
+	Sensitivity string
+	Action      RedactionAction
+	// StrippedKeys are the data keys that were removed from the block.
+	StrippedKeys []string
+	// Removed is the number of blocks that were removed, including
+	// descendants.
+	Removed int
+}
+
+// Redact applies the redaction policy to all blocks in the document and
+// returns a report of the redactions. Redact the Clone() of a document to
+// keep the original.
+func Redact(doc *Document, policy RedactionPolicy) RedactionReport {
+	r := redactor{policy: policy}
+
+	doc.Content = r.redactBlocks(doc.Content, BlockKindContent, nil)
+	doc.Meta = r.redactBlocks(doc.Meta, BlockKindMeta, nil)
+	doc.Links = r.redactBlocks(doc.Links, BlockKindLinks, nil)
+
+	for _, e := range r.report.Entries {
+		r.report.Removed += e.Removed
+	}
+
+	return r.report
+}
+
+type redactor struct {
+	policy RedactionPolicy
+	report RedactionReport
+}
+
+func (r *redactor) redactBlocks(
+	blocks []Block, kind BlockKind, parent BlockPath,
+) []Block {
+	if len(blocks) == 0 {
+		return blocks
+	}
+
+	res := make([]Block, 0, len(blocks))
+
+	for i, b := range blocks {
+		path := append(slices.Clip(parent), PathStep{
+			Kind:  kind,
+			Index: i,
+		})
+
+		rule := r.policy.rule(b.Sensitivity)
+
+		if rule.Action == RedactKeep {
+			res = append(res, r.redactChildren(b, path))
+
+			continue
+		}
+
+		// The entry is added before the children are redacted so that
+		// the report follows document order.
+		idx := len(r.report.Entries)
+
+		r.report.Entries = append(r.report.Entries, RedactionEntry{
+			Path:        path,
+			Type:        b.Type,
+			Sensitivity: b.Sensitivity,
+			Action:      rule.Action,
+		})
+
+		switch rule.Action {
+		case RedactKeep:
+		case RedactDropSubtree:
+			r.report.Entries[idx].Removed = countBlocks(b)
+		case RedactDropBlock:
+			children := childBlocks(b, kind)
+
+			r.report.Entries[idx].Removed = countBlocks(b) - countBlocksIn(children)
+
+			res = append(res, r.redactBlocks(children, kind, path)...)
+		case RedactStripData:
+			r.report.Entries[idx].StrippedKeys = stripData(&b, rule.DataKeys)
+
+			res = append(res, r.redactChildren(b, path))
+		case RedactReplace:
+			var (
+				nb   Block
+				keep bool
+			)
+
+			if rule.Replace != nil {
+				nb, keep = rule.Replace(path, b)
+			}
+
+			if !keep {
+				r.report.Entries[idx].Removed = countBlocks(b)
+
+				break
+			}
+
+			res = append(res, r.redactChildren(nb, path))
+		}
+	}
+
+	return res
+}
+
+func (r *redactor) redactChildren(b Block, path BlockPath) Block {
+	b.Content = r.redactBlocks(b.Content, BlockKindContent, path)
+	b.Meta = r.redactBlocks(b.Meta, BlockKindMeta, path)
+	b.Links = r.redactBlocks(b.Links, BlockKindLinks, path)
+
+	return b
+}
+
+// stripData removes the keys from the block data, or all data if no keys are
+// given. Returns the keys that were removed.
+func stripData(b *Block, keys []string) []string {
+	var stripped []string
+
+	if len(keys) == 0 {
+		for k := range b.Data {
+			stripped = append(stripped, k)
+		}
+
+		slices.Sort(stripped)
+
+		b.Data = nil
+
+		return stripped
+	}
+
+	if b.Data == nil {
+		return nil
+	}
+
+	data := make(DataMap, len(b.Data))
+
+	for k, v := range b.Data {
+		if slices.Contains(keys, k) {
+			stripped = append(stripped, k)
+
+			continue
+		}
+
+		data[k] = v
+	}
+
+	slices.Sort(stripped)
+
+	b.Data = data
+
+	return stripped
+}
+
+// countBlocks returns the number of blocks in the subtree of the block,
+// including the block itself.
+func countBlocks(b Block) int {
+	return 1 + countBlocksIn(b.Content) +
+		countBlocksIn(b.Meta) + countBlocksIn(b.Links)
+}
+
+func countBlocksIn(blocks []Block) int {
+	var n int
+
+	for _, b := range blocks {
+		n += countBlocks(b)
+	}
+
+	return n
+}
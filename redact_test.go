@@ -0,0 +1,141 @@
+package newsdoc_test
+
+import (
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func redactionDocument() newsdoc.Document {
+	return newsdoc.Document{
+		Content: []newsdoc.Block{
+			{Type: coreText, Value: "public"},
+			{
+				Type:        "core/factbox",
+				Sensitivity: "wrapper",
+				Meta:        []newsdoc.Block{{Type: "core/note"}},
+				Content: []newsdoc.Block{
+					{Type: coreText, Value: "fact"},
+					{Type: coreText, Value: "secret", Sensitivity: internal},
+				},
+			},
+		},
+		Meta: []newsdoc.Block{
+			{
+				Type:        "core/assignment",
+				Sensitivity: "personal",
+				Data: newsdoc.DataMap{
+					"phone": "+46 70 000 00 00",
+					"email": "someone@example.com",
+					"start": "2024-01-01",
+				},
+				Links: []newsdoc.Block{
+					{Type: "core/author", Rel: "assignee", Sensitivity: "source"},
+				},
+			},
+			{
+				Type:        "core/note",
+				Sensitivity: internal,
+				Content:     []newsdoc.Block{{Type: coreText}},
+			},
+		},
+		Links: []newsdoc.Block{
+			{Type: "core/section", Rel: "section", Sensitivity: "unknown"},
+		},
+	}
+}
+
+func TestRedact(t *testing.T) {
+	doc := redactionDocument()
+	original := redactionDocument()
+
+	policy := newsdoc.RedactionPolicy{
+		Levels: map[string]newsdoc.RedactionRule{
+			internal:  {Action: newsdoc.RedactDropSubtree},
+			"wrapper": {Action: newsdoc.RedactDropBlock},
+			"personal": {
+				Action:   newsdoc.RedactStripData,
+				DataKeys: []string{"phone", "email"},
+			},
+			"source": {
+				Action: newsdoc.RedactReplace,
+				Replace: func(_ newsdoc.BlockPath, b newsdoc.Block) (newsdoc.Block, bool) {
+					return newsdoc.Block{Type: b.Type, Rel: b.Rel, Title: "Anonymous"}, true
+				},
+			},
+		},
+		Default: newsdoc.RedactionRule{Action: newsdoc.RedactDropSubtree},
+	}
+
+	report := newsdoc.Redact(&doc, policy)
+
+	want := newsdoc.Document{
+		Content: []newsdoc.Block{
+			{Type: coreText, Value: "public"},
+			{Type: coreText, Value: "fact"},
+		},
+		Meta: []newsdoc.Block{
+			{
+				Type:        "core/assignment",
+				Sensitivity: "personal",
+				Data:        newsdoc.DataMap{"start": "2024-01-01"},
+				Links: []newsdoc.Block{
+					{Type: "core/author", Rel: "assignee", Title: "Anonymous"},
+				},
+			},
+		},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, doc, test.EquateEmpty{}.CmpOpts(),
+		"redacted document")
+
+	test.EqualDiffWithOptionsf(t, redactionDocument(), original, nil,
+		"the original blocks must be left untouched")
+
+	var got []string
+
+	for _, e := range report.Entries {
+		got = append(got, e.Path.String()+" "+e.Action.String())
+	}
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"content[1] drop-block",
+		"content[1].content[1] drop-subtree",
+		"meta[0] strip-data",
+		"meta[0].links[0] replace",
+		"meta[1] drop-subtree",
+		"links[0] drop-subtree",
+	}, got, nil, "report entries")
+
+	test.EqualDiffWithOptionsf(t, []string{"email", "phone"},
+		report.Entries[2].StrippedKeys, nil, "stripped keys")
+
+	// The factbox and its note, the secret text, the internal note and
+	// its text, and the section link.
+	if report.Removed != 6 {
+		t.Errorf("expected 6 removed blocks, got %d", report.Removed)
+	}
+}
+
+func TestRedactDefaultKeep(t *testing.T) {
+	doc := redactionDocument()
+
+	report := newsdoc.Redact(&doc, newsdoc.RedactionPolicy{
+		Levels: map[string]newsdoc.RedactionRule{
+			internal: {Action: newsdoc.RedactReplace},
+		},
+	})
+
+	test.EqualDiffWithOptionsf(t, []string{"public"},
+		[]string{doc.Content[0].Value}, nil, "kept content")
+
+	if len(doc.Meta) != 1 || len(doc.Links) != 1 {
+		t.Errorf("expected only the internal note to be removed from meta, got %d meta and %d links",
+			len(doc.Meta), len(doc.Links))
+	}
+
+	if report.Removed != 3 {
+		t.Errorf("expected 3 removed blocks, got %d", report.Removed)
+	}
+}
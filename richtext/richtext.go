@@ -0,0 +1,504 @@
+// Package richtext is a model for the formatted inline text that content
+// blocks store in f.ex. data.text.
+//
+// Text is a sequence of runs, where each run has a set of marks, like bold or
+// link. Positions and lengths are counted in characters (runes), and line
+// breaks are represented as newlines. Operations return new values and never
+// modify the text they're called on.
+package richtext
+
+import (
+	"cmp"
+	"html"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ttab/newsdoc/htmlcontent"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MarkType is a kind of inline formatting.
+type MarkType string
+
+// Mark types for the supported inline formatting.
+const (
+	Link        MarkType = "link"
+	Bold        MarkType = "bold"
+	Italic      MarkType = "italic"
+	Underline   MarkType = "underline"
+	Strike      MarkType = "strike"
+	Highlight   MarkType = "highlight"
+	Subscript   MarkType = "subscript"
+	Superscript MarkType = "superscript"
+	Code        MarkType = "code"
+)
+
+// markOrder is the order that marks are sorted and nested in, the first mark
+// is the outermost element when serialised.
+var markOrder = []MarkType{
+	Link, Bold, Italic, Underline, Strike, Highlight, Subscript,
+	Superscript, Code,
+}
+
+var markTags = map[MarkType]atom.Atom{
+	Link:        atom.A,
+	Bold:        atom.Strong,
+	Italic:      atom.Em,
+	Underline:   atom.U,
+	Strike:      atom.S,
+	Highlight:   atom.Mark,
+	Subscript:   atom.Sub,
+	Superscript: atom.Sup,
+	Code:        atom.Code,
+}
+
+var tagMarks = map[atom.Atom]MarkType{
+	atom.A:      Link,
+	atom.B:      Bold,
+	atom.Strong: Bold,
+	atom.I:      Italic,
+	atom.Em:     Italic,
+	atom.U:      Underline,
+	atom.S:      Strike,
+	atom.Mark:   Highlight,
+	atom.Sub:    Subscript,
+	atom.Sup:    Superscript,
+	atom.Code:   Code,
+}
+
+// Mark is inline formatting applied to a run. Href is only used by links.
+type Mark struct {
+	Type MarkType `json:"type"`
+	Href string   `json:"href,omitempty"`
+}
+
+func compareMarks(a, b Mark) int {
+	ai := slices.Index(markOrder, a.Type)
+	bi := slices.Index(markOrder, b.Type)
+
+	// Unknown mark types are sorted last.
+	if ai == -1 {
+		ai = len(markOrder)
+	}
+
+	if bi == -1 {
+		bi = len(markOrder)
+	}
+
+	return cmp.Or(
+		cmp.Compare(ai, bi),
+		cmp.Compare(a.Type, b.Type),
+		cmp.Compare(a.Href, b.Href),
+	)
+}
+
+// Run is a piece of text with the same marks.
+type Run struct {
+	Text  string `json:"text"`
+	Marks []Mark `json:"marks,omitempty"`
+}
+
+// HasMark reports whether the run has the mark.
+func (r Run) HasMark(m Mark) bool {
+	return slices.Contains(r.Marks, m)
+}
+
+// HasMarkType reports whether the run has a mark of the given type.
+func (r Run) HasMarkType(mt MarkType) bool {
+	return slices.ContainsFunc(r.Marks, func(m Mark) bool {
+		return m.Type == mt
+	})
+}
+
+// withMark returns the run with the mark added, replacing any other mark of
+// the same type.
+func (r Run) withMark(m Mark) Run {
+	r = r.withoutMark(m.Type)
+
+	r.Marks = append(r.Marks, m)
+
+	return r
+}
+
+// withoutMark returns the run with all marks of the type removed.
+func (r Run) withoutMark(mt MarkType) Run {
+	r.Marks = slices.DeleteFunc(slices.Clone(r.Marks), func(m Mark) bool {
+		return m.Type == mt
+	})
+
+	return r
+}
+
+// Text is formatted text. The zero value is empty text.
+type Text []Run
+
+// Plain creates text without formatting.
+func Plain(s string) Text {
+	return Text{{Text: s}}.normalize()
+}
+
+// Parse reads inline HTML. The HTML is sanitised first, see
+// htmlcontent.SanitizeInline(), so anything that isn't the supported inline
+// formatting is dropped. Both b and strong are read as bold, and both i and
+// em as italic.
+func Parse(s string) Text {
+	type openTag struct {
+		tag  atom.Atom
+		mark Mark
+	}
+
+	var (
+		res   Text
+		stack []openTag
+	)
+
+	marks := func() []Mark {
+		var r Run
+
+		for _, o := range stack {
+			r = r.withMark(o.mark)
+		}
+
+		return r.Marks
+	}
+
+	z := xhtml.NewTokenizer(strings.NewReader(htmlcontent.SanitizeInline(s)))
+
+	for {
+		tt := z.Next()
+
+		switch tt {
+		case xhtml.ErrorToken:
+			return res.normalize()
+		case xhtml.TextToken:
+			res = append(res, Run{
+				Text:  string(z.Text()),
+				Marks: marks(),
+			})
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			tok := z.Token()
+
+			if tok.DataAtom == atom.Br {
+				res = append(res, Run{Text: "\n", Marks: marks()})
+
+				continue
+			}
+
+			mt, ok := tagMarks[tok.DataAtom]
+			if !ok || tt == xhtml.SelfClosingTagToken {
+				continue
+			}
+
+			m := Mark{Type: mt}
+
+			for _, a := range tok.Attr {
+				if mt == Link && a.Key == "href" {
+					m.Href = a.Val
+				}
+			}
+
+			stack = append(stack, openTag{tag: tok.DataAtom, mark: m})
+		case xhtml.EndTagToken:
+			tok := z.Token()
+
+			// Close the innermost open element of the type.
+			idx := len(stack) - 1
+			for idx >= 0 && stack[idx].tag != tok.DataAtom {
+				idx--
+			}
+
+			if idx != -1 {
+				stack = slices.Delete(stack, idx, idx+1)
+			}
+		case xhtml.CommentToken, xhtml.DoctypeToken:
+		}
+	}
+}
+
+// String serialises the text as inline HTML. Marks are written as the
+// elements a, strong, em, u, s, mark, sub, sup and code, and line breaks as
+// br. Marks of unknown types are left out.
+func (t Text) String() string {
+	var (
+		sb   strings.Builder
+		open []Mark
+	)
+
+	for _, r := range t.normalize() {
+		marks := slices.DeleteFunc(slices.Clone(r.Marks), func(m Mark) bool {
+			_, known := markTags[m.Type]
+
+			return !known
+		})
+
+		// Keep the open elements that the run also has, and close
+		// the rest.
+		keep := 0
+
+		for keep < len(open) && slices.Contains(marks, open[keep]) {
+			keep++
+		}
+
+		for i := len(open) - 1; i >= keep; i-- {
+			writeEndTag(&sb, open[i])
+		}
+
+		open = open[:keep]
+
+		for _, m := range marks {
+			if slices.Contains(open, m) {
+				continue
+			}
+
+			writeStartTag(&sb, m)
+
+			open = append(open, m)
+		}
+
+		lines := strings.Split(r.Text, "\n")
+
+		for i, line := range lines {
+			if i > 0 {
+				sb.WriteString("<br>")
+			}
+
+			sb.WriteString(html.EscapeString(line))
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		writeEndTag(&sb, open[i])
+	}
+
+	return sb.String()
+}
+
+func writeStartTag(sb *strings.Builder, m Mark) {
+	tag := markTags[m.Type].String()
+
+	sb.WriteByte('<')
+	sb.WriteString(tag)
+
+	if m.Type == Link && m.Href != "" {
+		sb.WriteString(` href="`)
+		sb.WriteString(html.EscapeString(m.Href))
+		sb.WriteByte('"')
+	}
+
+	sb.WriteByte('>')
+}
+
+func writeEndTag(sb *strings.Builder, m Mark) {
+	sb.WriteString("</")
+	sb.WriteString(markTags[m.Type].String())
+	sb.WriteByte('>')
+}
+
+// PlainText returns the text without formatting.
+func (t Text) PlainText() string {
+	var sb strings.Builder
+
+	for _, r := range t {
+		sb.WriteString(r.Text)
+	}
+
+	return sb.String()
+}
+
+// Length returns the number of characters in the text.
+func (t Text) Length() int {
+	var n int
+
+	for _, r := range t {
+		n += utf8.RuneCountInString(r.Text)
+	}
+
+	return n
+}
+
+// Slice returns the text between the start and end positions. Positions are
+// clamped to the text, so that out of range positions don't panic.
+func (t Text) Slice(start, end int) Text {
+	_, mid, _ := t.split3(start, end)
+
+	return mid
+}
+
+// Insert inserts text at the position. The inserted text keeps its own
+// formatting.
+func (t Text) Insert(pos int, other Text) Text {
+	left, right := t.split(pos)
+
+	return concat(left, other, right)
+}
+
+// InsertString inserts unformatted text at the position. The inserted text
+// gets the same marks as the character before the position, or the first
+// character if inserted at the start.
+func (t Text) InsertString(pos int, s string) Text {
+	left, right := t.split(pos)
+
+	r := Run{Text: s}
+
+	switch {
+	case len(left) > 0:
+		r.Marks = left[len(left)-1].Marks
+	case len(right) > 0:
+		r.Marks = right[0].Marks
+	}
+
+	return concat(left, Text{r}, right)
+}
+
+// Delete removes the text between the start and end positions.
+func (t Text) Delete(start, end int) Text {
+	left, _, right := t.split3(start, end)
+
+	return concat(left, right)
+}
+
+// AddMark adds a mark to the text between the start and end positions. Any
+// other mark of the same type is replaced, so that f.ex. a link can be
+// changed by adding a link with another href.
+func (t Text) AddMark(start, end int, m Mark) Text {
+	left, mid, right := t.split3(start, end)
+
+	for i := range mid {
+		mid[i] = mid[i].withMark(m)
+	}
+
+	return concat(left, mid, right)
+}
+
+// RemoveMark removes all marks of the type from the text between the start
+// and end positions.
+func (t Text) RemoveMark(start, end int, mt MarkType) Text {
+	left, mid, right := t.split3(start, end)
+
+	for i := range mid {
+		mid[i] = mid[i].withoutMark(mt)
+	}
+
+	return concat(left, mid, right)
+}
+
+// HasMark reports whether all the text between the start and end positions
+// has the mark. It returns false for an empty range.
+func (t Text) HasMark(start, end int, m Mark) bool {
+	_, mid, _ := t.split3(start, end)
+
+	if len(mid) == 0 {
+		return false
+	}
+
+	for _, r := range mid {
+		if !r.HasMark(m) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ToggleMark removes the mark from the text between the start and end
+// positions if all of it has the mark, otherwise the mark is added.
+func (t Text) ToggleMark(start, end int, m Mark) Text {
+	if t.HasMark(start, end, m) {
+		return t.RemoveMark(start, end, m.Type)
+	}
+
+	return t.AddMark(start, end, m)
+}
+
+// split splits the text at the position.
+func (t Text) split(pos int) (Text, Text) {
+	var left, right Text
+
+	pos = max(pos, 0)
+
+	for _, r := range t {
+		n := utf8.RuneCountInString(r.Text)
+
+		switch {
+		case pos >= n:
+			left = append(left, r)
+			pos -= n
+		case pos <= 0:
+			right = append(right, r)
+		default:
+			idx := byteOffset(r.Text, pos)
+
+			left = append(left, Run{Text: r.Text[:idx], Marks: r.Marks})
+			right = append(right, Run{Text: r.Text[idx:], Marks: r.Marks})
+			pos = 0
+		}
+	}
+
+	return left, right
+}
+
+// split3 splits the text at the start and end positions.
+func (t Text) split3(start, end int) (Text, Text, Text) {
+	start = max(start, 0)
+	end = max(end, start)
+
+	left, rest := t.split(start)
+	mid, right := rest.split(end - start)
+
+	return left, mid, right
+}
+
+func byteOffset(s string, runes int) int {
+	for i := range s {
+		if runes == 0 {
+			return i
+		}
+
+		runes--
+	}
+
+	return len(s)
+}
+
+func concat(parts ...Text) Text {
+	var res Text
+
+	for _, p := range parts {
+		res = append(res, p...)
+	}
+
+	return res.normalize()
+}
+
+// normalize returns a copy of the text where empty runs are removed, marks
+// are sorted, and adjacent runs with the same marks are merged.
+func (t Text) normalize() Text {
+	var res Text
+
+	for _, r := range t {
+		if r.Text == "" {
+			continue
+		}
+
+		marks := slices.Clone(r.Marks)
+
+		slices.SortFunc(marks, compareMarks)
+
+		marks = slices.Compact(marks)
+		if len(marks) == 0 {
+			marks = nil
+		}
+
+		if len(res) > 0 && slices.Equal(res[len(res)-1].Marks, marks) {
+			res[len(res)-1].Text += r.Text
+
+			continue
+		}
+
+		res = append(res, Run{Text: r.Text, Marks: marks})
+	}
+
+	return res
+}
@@ -0,0 +1,128 @@
+package richtext_test
+
+import (
+	"testing"
+
+	"github.com/ttab/newsdoc/internal/test"
+	"github.com/ttab/newsdoc/richtext"
+)
+
+func TestParse(t *testing.T) {
+	got := richtext.Parse(
+		`Fans &amp; <b>players <i>celebrated</i></b> ` +
+			`<a href="https://example.com/gallery">on the <em>ice</em></a>.<br>` +
+			`<span>Unknown</span> <script>x()</script>markup`)
+
+	link := richtext.Mark{Type: richtext.Link, Href: "https://example.com/gallery"}
+
+	want := richtext.Text{
+		{Text: "Fans & "},
+		{Text: "players ", Marks: []richtext.Mark{{Type: richtext.Bold}}},
+		{Text: "celebrated", Marks: []richtext.Mark{
+			{Type: richtext.Bold}, {Type: richtext.Italic},
+		}},
+		{Text: " "},
+		{Text: "on the ", Marks: []richtext.Mark{link}},
+		{Text: "ice", Marks: []richtext.Mark{link, {Type: richtext.Italic}}},
+		{Text: ".\nUnknown markup"},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, got, nil, "must parse runs")
+
+	test.EqualDiffWithOptionsf(t,
+		`Fans &amp; <strong>players <em>celebrated</em></strong> `+
+			`<a href="https://example.com/gallery">on the <em>ice</em></a>.<br>`+
+			`Unknown markup`,
+		got.String(), nil, "must serialise to canonical HTML")
+
+	test.EqualDiffWithOptionsf(t, 52, got.Length(), nil,
+		"must count characters")
+}
+
+func TestParseNestedSameType(t *testing.T) {
+	got := richtext.Parse(
+		`<a href="https://example.com/a">a <a href="https://example.com/b">b</a> c</a>`)
+
+	outer := richtext.Mark{Type: richtext.Link, Href: "https://example.com/a"}
+	inner := richtext.Mark{Type: richtext.Link, Href: "https://example.com/b"}
+
+	want := richtext.Text{
+		{Text: "a ", Marks: []richtext.Mark{outer}},
+		{Text: "b", Marks: []richtext.Mark{inner}},
+		{Text: " c", Marks: []richtext.Mark{outer}},
+	}
+
+	test.EqualDiffWithOptionsf(t, want, got, nil,
+		"end tags must close the innermost mark of the type")
+}
+
+func TestSerialiseNesting(t *testing.T) {
+	text := richtext.Plain("bold both italic").
+		AddMark(0, 9, richtext.Mark{Type: richtext.Bold}).
+		AddMark(5, 16, richtext.Mark{Type: richtext.Italic})
+
+	test.EqualDiffWithOptionsf(t,
+		"<strong>bold <em>both</em></strong><em> italic</em>",
+		text.String(), nil, "must nest overlapping marks")
+
+	test.EqualDiffWithOptionsf(t, text, richtext.Parse(text.String()), nil,
+		"must parse serialised text")
+}
+
+func TestSliceInsertDelete(t *testing.T) {
+	text := richtext.Parse("Hej <strong>världen</strong>!")
+
+	test.EqualDiffWithOptionsf(t, "<strong>värld</strong>",
+		text.Slice(4, 9).String(), nil, "must slice by characters")
+
+	test.EqualDiffWithOptionsf(t, "Hej <strong>världen</strong>!",
+		text.Slice(-5, 100).String(), nil, "must clamp positions")
+
+	test.EqualDiffWithOptionsf(t, 0, text.Slice(8, 2).Length(), nil,
+		"must return empty text for an inverted range")
+
+	test.EqualDiffWithOptionsf(t,
+		"Hej <strong>hela </strong><em>stora</em> <strong>världen</strong>!",
+		text.Insert(4, richtext.Parse("<b>hela </b><i>stora</i> ")).String(),
+		nil, "must insert formatted text")
+
+	test.EqualDiffWithOptionsf(t, "Hej <strong>världen och solen</strong>!",
+		text.InsertString(11, " och solen").String(), nil,
+		"inserted strings must inherit marks")
+
+	test.EqualDiffWithOptionsf(t, "Hej!", text.Delete(3, 11).String(), nil,
+		"must delete text")
+
+	test.EqualDiffWithOptionsf(t, "Hej <strong>världen</strong>!",
+		text.String(), nil, "must not modify the original")
+}
+
+func TestToggleMark(t *testing.T) {
+	bold := richtext.Mark{Type: richtext.Bold}
+	text := richtext.Parse("Mostly <b>bold</b> text")
+
+	test.EqualDiffWithOptionsf(t, "Mostly bold text",
+		text.ToggleMark(7, 11, bold).String(), nil,
+		"must remove mark when the whole range has it")
+
+	test.EqualDiffWithOptionsf(t, "Mostly <strong>bold text</strong>",
+		text.ToggleMark(7, 16, bold).String(), nil,
+		"must add mark when only part of the range has it")
+
+	link := richtext.Mark{Type: richtext.Link, Href: "https://example.com/a"}
+	other := richtext.Mark{Type: richtext.Link, Href: "https://example.com/b"}
+
+	linked := text.ToggleMark(0, 6, link).ToggleMark(0, 6, other)
+
+	test.EqualDiffWithOptionsf(t,
+		`<a href="https://example.com/b">Mostly</a> <strong>bold</strong> text`,
+		linked.String(), nil, "must replace links with another href")
+
+	if linked.HasMark(0, 6, link) || !linked.HasMark(0, 6, other) {
+		t.Fatal("expected the link to have been replaced")
+	}
+
+	if linked.HasMark(3, 3, other) {
+		t.Fatal("expected an empty range to not have marks")
+	}
+}
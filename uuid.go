@@ -0,0 +1,136 @@
+package newsdoc
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// URINamespace is the namespace used to derive UUIDs from URIs, the RFC 9562
+// URL namespace. This makes UUIDFromURI() compatible with f.ex. Python's
+// uuid.uuid5(uuid.NAMESPACE_URL, uri).
+const URINamespace = "6ba7b811-9dad-11d1-80b4-00c04fd430c8"
+
+var uriNamespace = uuid.MustParse(URINamespace)
+
+// UUIDFromURI derives a v5 UUID from a URI using the URINamespace.
+func UUIDFromURI(uri string) string {
+	return uuid.NewSHA1(uriNamespace, []byte(uri)).String()
+}
+
+// IdentifierProblem is an invalid UUID, URI or URL in a document.
+type IdentifierProblem struct {
+	// Path is the path to the block with the problem, or nil if the
+	// problem is with the document itself.
+	Path BlockPath
+	// Attribute is the attribute with the problem: "uuid", "uri" or
+	// "url".
+	Attribute string
+	Value     string
+	Message   string
+}
+
+// String returns a description of the problem, f.ex. `links[1] uuid "x": not
+// a valid UUID`.
+func (p IdentifierProblem) String() string {
+	location := "document"
+	if len(p.Path) > 0 {
+		location = p.Path.String()
+	}
+
+	return location + " " + p.Attribute + " " + strconv.Quote(p.Value) + ": " + p.Message
+}
+
+// ValidateIdentifiers checks the UUIDs, URIs and URLs of the document and all
+// its blocks. UUIDs must be in the canonical hyphenated form, and URIs and URLs
+// must be absolute. Empty values are not checked.
+func ValidateIdentifiers(doc Document) []IdentifierProblem {
+	var problems []IdentifierProblem
+
+	check := func(path BlockPath, uuidValue, uriValue, urlValue string) {
+		if uuidValue != "" {
+			if msg := checkUUID(uuidValue); msg != "" {
+				problems = append(problems, IdentifierProblem{
+					Path:      path,
+					Attribute: "uuid",
+					Value:     uuidValue,
+					Message:   msg,
+				})
+			}
+		}
+
+		for _, attr := range [][2]string{{"uri", uriValue}, {"url", urlValue}} {
+			if attr[1] == "" {
+				continue
+			}
+
+			if msg := checkURI(attr[1]); msg != "" {
+				problems = append(problems, IdentifierProblem{
+					Path:      path,
+					Attribute: attr[0],
+					Value:     attr[1],
+					Message:   msg,
+				})
+			}
+		}
+	}
+
+	check(nil, doc.UUID, doc.URI, doc.URL)
+
+	inspectDocument(doc, newDeepOptions(nil), func(path BlockPath, b *Block) walkAction {
+		check(path, b.UUID, b.URI, b.URL)
+
+		return walkContinue
+	})
+
+	return problems
+}
+
+func checkUUID(value string) string {
+	// uuid.Parse() also accepts the URN and braced forms, but the schema
+	// requires the plain hyphenated form.
+	if len(value) != 36 {
+		return "not a valid UUID"
+	}
+
+	err := uuid.Validate(value)
+	if err != nil {
+		return "not a valid UUID"
+	}
+
+	return ""
+}
+
+func checkURI(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return "not a valid URI"
+	}
+
+	if !u.IsAbs() {
+		return "not an absolute URI"
+	}
+
+	return ""
+}
+
+// DeriveLinkUUIDs sets the UUID of link blocks anywhere in the document that
+// have a URI but no UUID to the UUID derived from the URI, see UUIDFromURI().
+// Returns the number of updated links.
+func DeriveLinkUUIDs(doc *Document) int {
+	var n int
+
+	walkDocument(doc, newDeepOptions(nil), func(path BlockPath, b *Block) walkAction {
+		if path[len(path)-1].Kind != BlockKindLinks || b.UUID != "" || b.URI == "" {
+			return walkContinue
+		}
+
+		b.UUID = UUIDFromURI(b.URI)
+		n++
+
+		return walkContinue
+	})
+
+	return n
+}
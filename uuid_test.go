@@ -0,0 +1,104 @@
+package newsdoc_test
+
+import (
+	"testing"
+
+	"github.com/ttab/newsdoc"
+	"github.com/ttab/newsdoc/internal/test"
+)
+
+func TestUUIDFromURI(t *testing.T) {
+	// Reference values from Python's uuid.uuid5(uuid.NAMESPACE_URL, uri).
+	cases := map[string]string{
+		"iptc://subject/15000000": "b754f52a-ef85-5284-bf6a-925370b17bb2",
+		"core://section/sports":   "e5377135-b2c3-5f4c-9afa-7403322ecf17",
+	}
+
+	for uri, want := range cases {
+		got := newsdoc.UUIDFromURI(uri)
+		if got != want {
+			t.Errorf("UUIDFromURI(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+func TestValidateIdentifiers(t *testing.T) {
+	doc := newsdoc.Document{
+		UUID: "not-a-uuid",
+		URI:  "core://article/1",
+		Meta: []newsdoc.Block{
+			{UUID: "{b754f52a-ef85-5284-bf6a-925370b17bb2}"},
+		},
+		Links: []newsdoc.Block{
+			{
+				UUID: "b754f52a-ef85-5284-bf6a-925370b17bb2",
+				URI:  "iptc://subject/15000000",
+				URL:  "/relative/path",
+				Links: []newsdoc.Block{
+					{URI: "%zz"},
+				},
+			},
+		},
+	}
+
+	var got []string
+
+	for _, p := range newsdoc.ValidateIdentifiers(doc) {
+		got = append(got, p.String())
+	}
+
+	test.EqualDiffWithOptionsf(t, []string{
+		`document uuid "not-a-uuid": not a valid UUID`,
+		`meta[0] uuid "{b754f52a-ef85-5284-bf6a-925370b17bb2}": not a valid UUID`,
+		`links[0] url "/relative/path": not an absolute URI`,
+		`links[0].links[0] uri "%zz": not a valid URI`,
+	}, got, nil, "identifier problems")
+}
+
+func TestValidateIdentifiersPlanning(t *testing.T) {
+	doc := loadPlanningDocument(t)
+
+	problems := newsdoc.ValidateIdentifiers(doc)
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestDeriveLinkUUIDs(t *testing.T) {
+	doc := newsdoc.Document{
+		Meta: []newsdoc.Block{
+			{
+				URI: "core://meta/1",
+				Links: []newsdoc.Block{
+					{URI: "core://section/sports", Rel: "section"},
+				},
+			},
+		},
+		Links: []newsdoc.Block{
+			{URI: "iptc://subject/15000000", Rel: "subject"},
+			{URI: "core://author/1", UUID: "7a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"},
+			{Rel: "related"},
+		},
+	}
+
+	n := newsdoc.DeriveLinkUUIDs(&doc)
+	if n != 2 {
+		t.Errorf("expected 2 updated links, got %d", n)
+	}
+
+	got := []string{
+		doc.Meta[0].UUID,
+		doc.Meta[0].Links[0].UUID,
+		doc.Links[0].UUID,
+		doc.Links[1].UUID,
+		doc.Links[2].UUID,
+	}
+
+	test.EqualDiffWithOptionsf(t, []string{
+		"",
+		"e5377135-b2c3-5f4c-9afa-7403322ecf17",
+		"b754f52a-ef85-5284-bf6a-925370b17bb2",
+		"7a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d",
+		"",
+	}, got, nil, "link UUIDs")
+}
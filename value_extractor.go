@@ -294,7 +294,30 @@ func ValueExtractorFromBytes(text []byte) (*ValueExtractor, error) {
 	return &ve, nil
 }
 
-func (ve *ValueExtractor) Collect(doc Document) []ExtractedItems {
+// CollectOption is an option for ValueExtractor.Collect().
+type CollectOption func(opts *collectOptions)
+
+type collectOptions struct {
+	blockSource bool
+}
+
+// WithBlockSource makes Collect() record the source block of each extracted
+// row, see ExtractedValue.Source.
+func WithBlockSource() CollectOption {
+	return func(opts *collectOptions) {
+		opts.blockSource = true
+	}
+}
+
+func (ve *ValueExtractor) Collect(
+	doc Document, options ...CollectOption,
+) []ExtractedItems {
+	var opts collectOptions
+
+	for _, o := range options {
+		o(&opts)
+	}
+
 	// If we don't have a selector the value extraction targets the document
 	// itself.
 	if len(ve.Selectors) == 0 {
@@ -306,111 +329,107 @@ func (ve *ValueExtractor) Collect(doc Document) []ExtractedItems {
 		return []ExtractedItems{docValues}
 	}
 
-	var blocks []Block
-
-	root := ve.Selectors[0]
-
-	switch root.Kind {
-	case BlockKindContent:
-		blocks = doc.Content
-	case BlockKindLinks:
-		blocks = doc.Links
-	case BlockKindMeta:
-		blocks = doc.Meta
-	}
-
-	matches := root.Iterator(slices.Values(blocks))
+	var extracts []ExtractedItems
 
-	for i := 1; i < len(ve.Selectors); i++ {
-		sel := ve.Selectors[i]
+	root := documentBlocks(doc, ve.Selectors[0].Kind)
 
-		var srcBlocks []iter.Seq[Block]
+	for path, b := range selectBlocks(root, ve.Selectors, opts.blockSource) {
+		e := ve.extractBlock(b)
+		if len(e) == 0 {
+			continue
+		}
 
-		for b := range matches {
-			switch sel.Kind {
-			case BlockKindContent:
-				srcBlocks = append(srcBlocks, slices.Values(b.Content))
-			case BlockKindLinks:
-				srcBlocks = append(srcBlocks, slices.Values(b.Links))
-			case BlockKindMeta:
-				srcBlocks = append(srcBlocks, slices.Values(b.Meta))
-			}
+		if opts.blockSource {
+			e.setSource(&BlockSource{
+				Path: path,
+				ID:   b.ID,
+				UUID: b.UUID,
+			})
 		}
 
-		matches = sel.Iterator(concatIter(srcBlocks...))
+		extracts = append(extracts, e)
 	}
 
-	if len(ve.ChildSelectors) > 0 {
-		childSelectors := ve.ChildSelectors
-		prev := matches
-
-		matches = func(yield func(Block) bool) {
-			for b := range prev {
-				if !hasMatchingChildren(b, childSelectors) {
-					continue
-				}
+	return extracts
+}
 
-				if !yield(b) {
-					return
-				}
-			}
-		}
+// selectBlocks returns an iterator over the blocks matching the selector chain,
+// starting with the blocks in root. The block paths are only tracked if
+// trackPath is true, otherwise the yielded paths will be nil.
+func selectBlocks(
+	root []Block, selectors []BlockSelector, trackPath bool,
+) iter.Seq2[BlockPath, Block] {
+	return func(yield func(BlockPath, Block) bool) {
+		walkSelectors(root, selectors, nil, trackPath, yield)
 	}
+}
 
-	var extracts []ExtractedItems
+func walkSelectors(
+	blocks []Block, selectors []BlockSelector, path BlockPath,
+	trackPath bool, yield func(BlockPath, Block) bool,
+) bool {
+	sel := selectors[0]
 
-	if ve.ValueKind == ValueKindBlock {
-		spec := ve.Values[0]
+	for i := range blocks {
+		if !sel.Matches(blocks[i]) {
+			continue
+		}
 
-		for b := range matches {
-			block := b
+		var p BlockPath
 
-			extracts = append(extracts, ExtractedItems{
-				spec.Name: {
-					Name:       spec.Name,
-					Block:      &block,
-					Annotation: spec.Annotation,
-				},
+		if trackPath {
+			p = append(slices.Clip(path), PathStep{
+				Kind:  sel.Kind,
+				Index: i,
 			})
 		}
 
-		return extracts
-	}
-
-	if ve.ValueKind == ValueKindCombined {
-		for b := range matches {
-			e := extractCombinedItems(b, ve.Values)
-			if len(e) == 0 {
-				continue
+		if len(selectors) == 1 {
+			if !yield(p, blocks[i]) {
+				return false
 			}
 
-			extracts = append(extracts, e)
+			continue
 		}
 
-		return extracts
+		next := childBlocks(blocks[i], selectors[1].Kind)
+
+		if !walkSelectors(next, selectors[1:], p, trackPath, yield) {
+			return false
+		}
 	}
 
-	var accessor func(b Block, name string) string
+	return true
+}
 
-	switch ve.ValueKind {
-	case ValueKindAttributes:
-		accessor = getBlockAttribute
-	case ValueKindData:
-		accessor = getBlockData
-	case ValueKindBlock, ValueKindCombined:
-		// Handled by the early returns above.
+// extractBlock extracts the values from a block that has been matched by the
+// selector chain. Returns nil if the block is rejected by the child selectors
+// or is missing required values.
+func (ve *ValueExtractor) extractBlock(b Block) ExtractedItems {
+	if len(ve.ChildSelectors) > 0 && !hasMatchingChildren(b, ve.ChildSelectors) {
+		return nil
 	}
 
-	for b := range matches {
-		e := extractItems(b, ve.Values, accessor)
-		if len(e) == 0 {
-			continue
-		}
+	switch ve.ValueKind {
+	case ValueKindBlock:
+		spec := ve.Values[0]
 
-		extracts = append(extracts, e)
+		return ExtractedItems{
+			spec.Name: {
+				Name:       spec.Name,
+				Block:      &b,
+				Annotation: spec.Annotation,
+			},
+		}
+	case ValueKindCombined:
+		return extractCombinedItems(b, ve.Values)
+	case ValueKindAttributes:
+		return extractItems(b, ve.Values, getBlockAttribute)
+	case ValueKindData:
+		return extractItems(b, ve.Values, getBlockData)
 	}
 
-	return extracts
+	return nil
 }
 
 func extractDocumentAttributes(doc Document, spec []ValueSpec) ExtractedItems {
@@ -468,6 +487,27 @@ func getDocumentAttribute(doc Document, name string) string {
 	return ""
 }
 
+func setDocumentAttribute(doc *Document, name string, value string) bool {
+	switch documentAttributeKey(name) {
+	case docAttrUUID:
+		doc.UUID = value
+	case docAttrType:
+		doc.Type = value
+	case docAttrURI:
+		doc.URI = value
+	case docAttrURL:
+		doc.URL = value
+	case docAttrTitle:
+		doc.Title = value
+	case docAttrLanguage:
+		doc.Language = value
+	default:
+		return false
+	}
+
+	return true
+}
+
 func extractItems(
 	b Block,
 	spec []ValueSpec,
@@ -580,6 +620,39 @@ func getBlockAttribute(block Block, name string) string {
 	}
 }
 
+func setBlockAttribute(block *Block, name string, value string) bool {
+	switch blockAttributeKey(name) {
+	case blockAttrUUID:
+		block.UUID = value
+	case blockAttrID:
+		block.ID = value
+	case blockAttrType:
+		block.Type = value
+	case blockAttrURI:
+		block.URI = value
+	case blockAttrURL:
+		block.URL = value
+	case blockAttrTitle:
+		block.Title = value
+	case blockAttrRel:
+		block.Rel = value
+	case blockAttrName:
+		block.Name = value
+	case blockAttrValue:
+		block.Value = value
+	case blockAttrContentType:
+		block.Contenttype = value
+	case blockAttrRole:
+		block.Role = value
+	case blockAttrSensitivity:
+		block.Sensitivity = value
+	default:
+		return false
+	}
+
+	return true
+}
+
 type ValueSpec struct {
 	Name       string
 	Source     ValueSource `json:",omitempty"`
@@ -590,12 +663,34 @@ type ValueSpec struct {
 
 type ExtractedItems map[string]ExtractedValue
 
+// setSource sets the source of all values in the row.
+func (ei ExtractedItems) setSource(source *BlockSource) {
+	for k, v := range ei {
+		v.Source = source
+		ei[k] = v
+	}
+}
+
 type ExtractedValue struct {
 	Name       string
 	Value      string `json:",omitempty"`
 	Block      *Block `json:",omitempty"`
 	Annotation string `json:",omitempty"`
 	Role       string `json:",omitempty"`
+	// Source is the block that the value was extracted from. It's only set
+	// when collecting with the WithBlockSource() option, and is shared by
+	// all values in a row.
+	Source *BlockSource `json:",omitempty"`
+}
+
+// BlockSource describes the block that a value was extracted from.
+type BlockSource struct {
+	// Path is the location of the block in the document.
+	Path BlockPath
+	// ID is the ID of the block, if any.
+	ID string `json:",omitempty"`
+	// UUID is the UUID of the block, if any.
+	UUID string `json:",omitempty"`
 }
 
 type BlockKind string
@@ -722,6 +817,101 @@ func (fn *FilterNode) Matches(b Block) bool {
 	}
 }
 
+// Match implements BlockMatcher.
+func (fn *FilterNode) Match(b Block) bool {
+	return fn.Matches(b)
+}
+
+// String returns the filter expression in the selector syntax, f.ex.
+// "type='core/thing' (value='a' or value='b')".
+func (fn *FilterNode) String() string {
+	if fn == nil {
+		return ""
+	}
+
+	var buf bytes.Buffer
+
+	fn.writeTo(&buf)
+
+	return buf.String()
+}
+
+func (fn *FilterNode) writeTo(buf *bytes.Buffer) {
+	switch fn.Op {
+	case FilterOpAnd:
+		for i := range fn.Children {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+
+			child := &fn.Children[i]
+
+			if child.Op == FilterOpOr {
+				buf.WriteByte('(')
+				child.writeTo(buf)
+				buf.WriteByte(')')
+
+				continue
+			}
+
+			child.writeTo(buf)
+		}
+	case FilterOpOr:
+		for i := range fn.Children {
+			if i > 0 {
+				buf.WriteString(" or ")
+			}
+
+			fn.Children[i].writeTo(buf)
+		}
+	default:
+		if fn.Data != nil {
+			buf.WriteString(fn.Data.String())
+
+			return
+		}
+
+		buf.WriteString(fn.Attr)
+		buf.WriteByte('=')
+		writeQuoted(buf, fn.Value)
+	}
+}
+
+// String returns the data filter in the selector syntax, f.ex. "data.date??".
+func (df DataFilter) String() string {
+	var buf bytes.Buffer
+
+	buf.Write(bDataDot)
+	buf.WriteString(df.Key)
+
+	switch df.Mode {
+	case DataFilterExact:
+		buf.WriteByte('=')
+		writeQuoted(&buf, df.Value)
+	case DataFilterExists:
+		buf.WriteString("?")
+	case DataFilterNonEmpty:
+		buf.WriteString("??")
+	}
+
+	return buf.String()
+}
+
+// writeQuoted writes a single-quoted value, escaping quotes and backslashes.
+func writeQuoted(buf *bytes.Buffer, value string) {
+	buf.WriteByte(bQuote)
+
+	for i := 0; i < len(value); i++ {
+		if value[i] == bQuote || value[i] == bBackslash {
+			buf.WriteByte(bBackslash)
+		}
+
+		buf.WriteByte(value[i])
+	}
+
+	buf.WriteByte(bQuote)
+}
+
 // BlockSelector selects blocks by kind and optional attribute/data filters.
 type BlockSelector struct {
 	Kind   BlockKind
@@ -742,6 +932,58 @@ func (bs BlockSelector) Iterator(blocks iter.Seq[Block]) iter.Seq[Block] {
 	}
 }
 
+// documentBlocks returns the top level blocks of the given kind.
+func documentBlocks(doc Document, kind BlockKind) []Block {
+	switch kind {
+	case BlockKindContent:
+		return doc.Content
+	case BlockKindLinks:
+		return doc.Links
+	case BlockKindMeta:
+		return doc.Meta
+	}
+
+	return nil
+}
+
+// childBlocks returns the child blocks of the given kind.
+func childBlocks(b Block, kind BlockKind) []Block {
+	switch kind {
+	case BlockKindContent:
+		return b.Content
+	case BlockKindLinks:
+		return b.Links
+	case BlockKindMeta:
+		return b.Meta
+	}
+
+	return nil
+}
+
+// setDocumentBlocks sets the top level blocks of the given kind.
+func setDocumentBlocks(doc *Document, kind BlockKind, blocks []Block) {
+	switch kind {
+	case BlockKindContent:
+		doc.Content = blocks
+	case BlockKindLinks:
+		doc.Links = blocks
+	case BlockKindMeta:
+		doc.Meta = blocks
+	}
+}
+
+// setChildBlocks sets the child blocks of the given kind.
+func setChildBlocks(b *Block, kind BlockKind, blocks []Block) {
+	switch kind {
+	case BlockKindContent:
+		b.Content = blocks
+	case BlockKindLinks:
+		b.Links = blocks
+	case BlockKindMeta:
+		b.Meta = blocks
+	}
+}
+
 // concatIter returns an iterator over the concatenation of the sequences.
 func concatIter[V any](seqs ...iter.Seq[V]) iter.Seq[V] {
 	return func(yield func(V) bool) {
@@ -803,10 +1045,25 @@ func hasMatchingChildren(b Block, selectors []BlockSelector) bool {
 	return false
 }
 
+// String returns the selector in the expression syntax, f.ex.
+// ".meta(type='core/event')".
+func (bs BlockSelector) String() string {
+	if bs.Filter == nil {
+		return "." + string(bs.Kind)
+	}
+
+	return "." + string(bs.Kind) + "(" + bs.Filter.String() + ")"
+}
+
 func (bs BlockSelector) FilterBlocks(blocks []Block) []Block {
 	return slices.Collect(bs.Iterator(slices.Values(blocks)))
 }
 
+// Match implements BlockMatcher.
+func (bs BlockSelector) Match(b Block) bool {
+	return bs.Filter.Matches(b)
+}
+
 func (bs BlockSelector) Matches(b Block) bool {
 	return bs.Filter.Matches(b)
 }
@@ -816,6 +1073,7 @@ var validAttributeKeys = map[string]struct{}{
 	"uuid":        {},
 	"uri":         {},
 	"url":         {},
+	"title":       {},
 	"type":        {},
 	"rel":         {},
 	"role":        {},
@@ -825,6 +1083,14 @@ var validAttributeKeys = map[string]struct{}{
 	"sensitivity": {},
 }
 
+// isBlockAttribute reports whether name is a block attribute that can be
+// extracted or used in filters.
+func isBlockAttribute(name string) bool {
+	_, ok := validAttributeKeys[name]
+
+	return ok
+}
+
 // validateAttributeKey checks that key is a known block attribute.
 func validateAttributeKey(key string) error {
 	if _, ok := validAttributeKeys[key]; !ok {
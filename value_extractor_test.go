@@ -916,3 +916,96 @@ func TestValueExtractor(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectorStringRoundTrip(t *testing.T) {
+	expressions := []string{
+		".meta(type='core/event' data.date?? data.status='confirmed')",
+		".meta(type='core/thing' (value='a' or value='b'))",
+		".meta((type='a' (value='x' or value='y')) or (type='b' value='z'))",
+		".meta(data.tag='it\\'s breaking' data.date?).links",
+		".content(value='back\\\\slash')",
+	}
+
+	for _, exp := range expressions {
+		ve, err := newsdoc.ValueExtractorFromString("b=" + exp)
+		test.Mustf(t, err, "parse expression %q", exp)
+
+		var str string
+
+		for _, sel := range ve.Selectors {
+			str += sel.String()
+		}
+
+		again, err := newsdoc.ValueExtractorFromString("b=" + str)
+		test.Mustf(t, err, "parse serialized expression %q", str)
+
+		test.EqualDiffWithOptionsf(t, ve.Selectors, again.Selectors, nil,
+			"round trip of %q", exp)
+	}
+}
+
+func TestCollectWithBlockSource(t *testing.T) {
+	var doc newsdoc.Document
+
+	err := test.UnmarshalFile(
+		filepath.Join("testdata", "TestValueExtractor", "planning.json"),
+		&doc)
+	test.Mustf(t, err, "unmarshal document")
+
+	ve, err := newsdoc.ValueExtractorFromString(
+		".meta(type='core/assignment').links@{uuid rel}")
+	test.Mustf(t, err, "parse expression")
+
+	results := ve.Collect(doc, newsdoc.WithBlockSource())
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	want := []string{"meta[5].links[0]", "meta[5].links[1]"}
+
+	for i, row := range results {
+		for name, v := range row {
+			if v.Source == nil {
+				t.Fatalf("row %d value %q has no source", i, name)
+			}
+
+			if v.Source.Path.String() != want[i] {
+				t.Errorf("row %d: expected path %q, got %q",
+					i, want[i], v.Source.Path)
+			}
+
+			if v.Source.UUID != row["uuid"].Value {
+				t.Errorf("row %d: expected source UUID %q, got %q",
+					i, row["uuid"].Value, v.Source.UUID)
+			}
+
+			b, ok := v.Source.Path.Get(doc)
+			if !ok || b.Rel != row["rel"].Value {
+				t.Errorf("row %d: path doesn't resolve to the source block", i)
+			}
+		}
+	}
+
+	blocks, err := newsdoc.ValueExtractorFromString(
+		"assignment=.meta(type='core/assignment')")
+	test.Mustf(t, err, "parse block expression")
+
+	results = blocks.Collect(doc, newsdoc.WithBlockSource())
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+
+	source := results[0]["assignment"].Source
+	if source == nil || source.ID != "54ed5440-1fa7-4516-a13c-dfd4166e9f37" {
+		t.Errorf("expected the block ID in the source, got %#v", source)
+	}
+
+	// Sources must not be recorded unless asked for.
+	for _, row := range ve.Collect(doc) {
+		for _, v := range row {
+			if v.Source != nil {
+				t.Error("source should only be set when requested")
+			}
+		}
+	}
+}